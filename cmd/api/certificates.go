@@ -0,0 +1,130 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/pharsha1995/greenlight/internal/data"
+	"github.com/pharsha1995/greenlight/internal/data/validator"
+)
+
+func (app *application) createClientCertificateHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Subject        string `json:"subject"`
+		UserID         int64  `json:"user_id"`
+		OrganizationID *int64 `json:"organization_id"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	cert := &data.ClientCertificate{
+		Subject:        input.Subject,
+		UserID:         input.UserID,
+		OrganizationID: input.OrganizationID,
+	}
+
+	v := validator.New()
+
+	if data.ValidateClientCertificate(v, cert); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	_, err = app.models.Users.Get(cert.UserID)
+	if err != nil {
+		if errors.Is(err, data.ErrNoRecord) {
+			v.AddError("user_id", "must be an existing user")
+			app.failedValidationResponse(w, r, v.Errors)
+		} else {
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if cert.OrganizationID != nil {
+		_, err = app.models.Organizations.GetMember(*cert.OrganizationID, cert.UserID)
+		if err != nil {
+			if errors.Is(err, data.ErrNoRecord) {
+				v.AddError("organization_id", "must be an organization the user is a member of")
+				app.failedValidationResponse(w, r, v.Errors)
+			} else {
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+	}
+
+	err = app.models.Certificates.Insert(cert)
+	if err != nil {
+		if errors.Is(err, data.ErrDuplicateCertificate) {
+			v.AddError("subject", "is already mapped to a user")
+			app.failedValidationResponse(w, r, v.Errors)
+		} else {
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusCreated, &envelope{"client_certificate": cert}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) listClientCertificatesHandler(w http.ResponseWriter, r *http.Request) {
+	certs, err := app.models.Certificates.GetAll()
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, &envelope{"client_certificates": certs}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) deleteClientCertificateHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	err = app.models.Certificates.Delete(id)
+	if err != nil {
+		if errors.Is(err, data.ErrNoRecord) {
+			app.notFoundResponse(w, r)
+		} else {
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, &envelope{"message": "client certificate mapping successfully deleted"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) authenticateCertificate(w http.ResponseWriter, r *http.Request, next http.Handler, subject string) {
+	cert, user, err := app.models.Certificates.GetForSubject(subject)
+	if err != nil {
+		if errors.Is(err, data.ErrNoRecord) {
+			app.unknownClientCertificateResponse(w, r)
+		} else {
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	r = app.contextSetUser(r, user)
+	r = app.contextSetToken(r, &data.Token{UserID: user.ID, Scope: data.ScopeCertificate, OrganizationID: cert.OrganizationID})
+
+	next.ServeHTTP(w, r)
+}
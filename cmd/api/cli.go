@@ -0,0 +1,112 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"strings"
+
+	"github.com/pharsha1995/greenlight/internal/data"
+	"github.com/pharsha1995/greenlight/internal/data/validator"
+)
+
+const cliUsage = `usage:
+  api [flags] permissions list
+  api [flags] permissions create <code>
+  api [flags] permissions grant <email> <code>...
+  api [flags] permissions revoke <email> <code>...
+
+Changes made here are not seen by running API servers until their
+permission cache expires (see -permissions-cache-ttl).`
+
+var errCLIUsage = errors.New(cliUsage)
+
+func (app *application) runCommand(args []string) error {
+	if len(args) < 2 || args[0] != "permissions" {
+		return errCLIUsage
+	}
+
+	actor := cliActor()
+
+	switch cmd, args := args[1], args[2:]; cmd {
+	case "list":
+		permissions, err := app.models.Permissions.GetAll()
+		if err != nil {
+			return err
+		}
+
+		for _, code := range permissions {
+			fmt.Println(code)
+		}
+
+	case "create":
+		if len(args) != 1 {
+			return errCLIUsage
+		}
+
+		v := validator.New()
+
+		if data.ValidatePermissionCode(v, "code", args[0]); !v.Valid() {
+			return errors.New(v.Errors["code"])
+		}
+
+		err := app.models.Permissions.Insert(actor, args[0])
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("created permission %s\n", args[0])
+
+	case "grant", "revoke":
+		if len(args) < 2 {
+			return errCLIUsage
+		}
+
+		user, err := app.models.Users.GetByEmail(args[0])
+		if err != nil {
+			if errors.Is(err, data.ErrNoRecord) {
+				return fmt.Errorf("no user with email %s", args[0])
+			}
+			return err
+		}
+
+		codes := args[1:]
+
+		v := validator.New()
+
+		if data.ValidatePermissionCodes(v, "codes", codes); !v.Valid() {
+			return errors.New(v.Errors["codes"])
+		}
+
+		action := "granted"
+
+		if cmd == "grant" {
+			err = app.models.Permissions.Grant(actor, user.ID, codes...)
+		} else {
+			action = "revoked"
+			err = app.models.Permissions.Revoke(actor, user.ID, codes...)
+		}
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("%s %s for %s\n", action, strings.Join(codes, ", "), user.Email)
+		fmt.Println("running API servers apply this once their permission cache expires")
+
+	default:
+		return errCLIUsage
+	}
+
+	return nil
+}
+
+func cliActor() data.Actor {
+	name := os.Getenv("USER")
+
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+
+	return data.Actor{Name: "cli:" + name}
+}
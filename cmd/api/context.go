@@ -9,7 +9,13 @@ import (
 
 type contextKey string
 
-const userContextKey = contextKey("user")
+const (
+	userContextKey        = contextKey("user")
+	tokenContextKey       = contextKey("token")
+	permissionsContextKey = contextKey("permissions")
+	scopeContextKey       = contextKey("scope")
+	memberContextKey      = contextKey("member")
+)
 
 func (app *application) contextSetUser(r *http.Request, user *data.User) *http.Request {
 	ctx := context.WithValue(r.Context(), userContextKey, user)
@@ -24,3 +30,47 @@ func (app *application) contextGetUser(r *http.Request) *data.User {
 
 	return user
 }
+
+func (app *application) contextSetToken(r *http.Request, token *data.Token) *http.Request {
+	ctx := context.WithValue(r.Context(), tokenContextKey, token)
+	return r.WithContext(ctx)
+}
+
+func (app *application) contextGetToken(r *http.Request) *data.Token {
+	token, ok := r.Context().Value(tokenContextKey).(*data.Token)
+	if !ok {
+		panic("missing token value in request context")
+	}
+
+	return token
+}
+
+func (app *application) contextSetPermissions(r *http.Request, permissions data.Permissions) *http.Request {
+	ctx := context.WithValue(r.Context(), permissionsContextKey, permissions)
+	return r.WithContext(ctx)
+}
+
+func (app *application) contextGetPermissions(r *http.Request) (data.Permissions, bool) {
+	permissions, ok := r.Context().Value(permissionsContextKey).(data.Permissions)
+	return permissions, ok
+}
+
+func (app *application) contextSetPermissionScope(r *http.Request, scope data.Permissions) *http.Request {
+	ctx := context.WithValue(r.Context(), scopeContextKey, scope)
+	return r.WithContext(ctx)
+}
+
+func (app *application) contextGetPermissionScope(r *http.Request) (data.Permissions, bool) {
+	scope, ok := r.Context().Value(scopeContextKey).(data.Permissions)
+	return scope, ok
+}
+
+func (app *application) contextSetMember(r *http.Request, member *data.Member) *http.Request {
+	ctx := context.WithValue(r.Context(), memberContextKey, member)
+	return r.WithContext(ctx)
+}
+
+func (app *application) contextGetMember(r *http.Request) (*data.Member, bool) {
+	member, ok := r.Context().Value(memberContextKey).(*data.Member)
+	return member, ok
+}
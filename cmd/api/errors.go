@@ -75,7 +75,61 @@ func (app *application) inactiveAccountResponse(w http.ResponseWriter, r *http.R
 	app.errorResponse(w, r, http.StatusForbidden, msg)
 }
 
+func (app *application) invalidSignatureResponse(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("WWW-Authenticate", signatureScheme)
+
+	msg := "invalid, expired or replayed request signature"
+	app.errorResponse(w, r, http.StatusUnauthorized, msg)
+}
+
+func (app *application) unknownClientCertificateResponse(w http.ResponseWriter, r *http.Request) {
+	msg := "client certificate is not mapped to a user"
+	app.errorResponse(w, r, http.StatusUnauthorized, msg)
+}
+
+func (app *application) invalidCSRFTokenResponse(w http.ResponseWriter, r *http.Request) {
+	msg := "invalid or missing CSRF token"
+	app.errorResponse(w, r, http.StatusForbidden, msg)
+}
+
+func (app *application) loginSessionRequiredResponse(w http.ResponseWriter, r *http.Request) {
+	msg := "this endpoint requires a user login session; API keys and delegated credentials are not accepted"
+	app.errorResponse(w, r, http.StatusForbidden, msg)
+}
+
 func (app *application) notPermittedResponse(w http.ResponseWriter, r *http.Request) {
 	msg := "your user account doesn't have the necessary permissions to access this resource"
 	app.errorResponse(w, r, http.StatusForbidden, msg)
 }
+
+func (app *application) organizationRequiredResponse(w http.ResponseWriter, r *http.Request) {
+	msg := "you must select an organization using the X-Organization-ID header"
+	app.errorResponse(w, r, http.StatusBadRequest, msg)
+}
+
+func (app *application) encryptionNotConfiguredResponse(w http.ResponseWriter, r *http.Request) {
+	msg := "this feature requires an encryption key which has not been configured on the server"
+	app.errorResponse(w, r, http.StatusNotImplemented, msg)
+}
+
+func (app *application) oidcNotConfiguredResponse(w http.ResponseWriter, r *http.Request) {
+	msg := "single sign-on has not been configured on the server"
+	app.errorResponse(w, r, http.StatusNotImplemented, msg)
+}
+
+func (app *application) oauthErrorResponse(w http.ResponseWriter, r *http.Request, status int, code, description string) {
+	env := envelope{"error": code, "error_description": description}
+
+	header := make(http.Header)
+	header.Set("Cache-Control", "no-store")
+
+	err := app.writeJSON(w, status, &env, header)
+	if err != nil {
+		app.logError(r, err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func (app *application) conflictResponse(w http.ResponseWriter, r *http.Request, msg string) {
+	app.errorResponse(w, r, http.StatusConflict, msg)
+}
@@ -10,6 +10,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/pharsha1995/greenlight/internal/data"
 	"github.com/pharsha1995/greenlight/internal/data/validator"
 )
 
@@ -117,6 +118,45 @@ func (app *application) readInt(qs url.Values, key string, defaultValue int, v *
 	}
 }
 
+func (app *application) readBool(qs url.Values, key string, v *validator.Validator) *bool {
+	if s := qs.Get(key); s == "" {
+		return nil
+	} else {
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			v.AddError(key, "must be a boolean value")
+			return nil
+		}
+
+		return &b
+	}
+}
+
+func (app *application) userPermissions(r *http.Request) (data.Permissions, error) {
+	if permissions, ok := app.contextGetPermissions(r); ok {
+		return permissions, nil
+	}
+
+	return app.models.Permissions.GetAllForUser(app.contextGetUser(r).ID)
+}
+
+func (app *application) hasPermission(r *http.Request, code string) (bool, error) {
+	permissions, err := app.userPermissions(r)
+	if err != nil {
+		return false, err
+	}
+
+	if !permissions.Include(code) {
+		return false, nil
+	}
+
+	if scope, ok := app.contextGetPermissionScope(r); ok && !scope.Include(code) {
+		return false, nil
+	}
+
+	return true, nil
+}
+
 func (app *application) background(fn func()) {
 	app.wg.Add(1)
 
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pharsha1995/greenlight/internal/data"
+	"github.com/pharsha1995/greenlight/internal/data/validator"
+)
+
+func (app *application) createInvitationHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Email            string   `json:"email"`
+		Permissions      []string `json:"permissions"`
+		OrganizationID   *int64   `json:"organization_id"`
+		OrganizationRole *string  `json:"organization_role"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	actor, err := app.currentActor(r)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	inv := &data.Invitation{
+		Email:            input.Email,
+		Permissions:      input.Permissions,
+		OrganizationID:   input.OrganizationID,
+		OrganizationRole: input.OrganizationRole,
+		InvitedBy:        &actor.ID,
+	}
+
+	if inv.Permissions == nil {
+		inv.Permissions = data.Permissions{}
+	}
+
+	v := validator.New()
+
+	if data.ValidateInvitation(v, inv); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	for _, code := range inv.Permissions {
+		if data.IsDenyPermission(code) {
+			continue
+		}
+
+		permitted, err := app.hasPermission(r, code)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+
+		if !permitted {
+			v.AddError("permissions", "must be a subset of your own permissions")
+			app.failedValidationResponse(w, r, v.Errors)
+			return
+		}
+	}
+
+	if inv.OrganizationID != nil {
+		member, err := app.models.Organizations.GetMember(*inv.OrganizationID, actor.ID)
+		if err != nil {
+			if errors.Is(err, data.ErrNoRecord) {
+				v.AddError("organization_id", "must be an organization you are a member of")
+				app.failedValidationResponse(w, r, v.Errors)
+			} else {
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+
+		if !member.HasRole(data.OrganizationRoleAdmin) || (*inv.OrganizationRole == data.OrganizationRoleOwner && !member.HasRole(data.OrganizationRoleOwner)) {
+			app.notPermittedResponse(w, r)
+			return
+		}
+	}
+
+	_, err = app.models.Users.GetByEmail(inv.Email)
+	if err == nil {
+		v.AddError("email", "user with this email already exists")
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	} else if !errors.Is(err, data.ErrNoRecord) {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.models.Invitations.Insert(inv, 7*24*time.Hour)
+	if err != nil {
+		if errors.Is(err, data.ErrUnknownPermission) {
+			v.AddError("permissions", "must only contain existing permission codes")
+			app.failedValidationResponse(w, r, v.Errors)
+		} else {
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	app.background(func() {
+		data := map[string]any{
+			"invitationToken": inv.Plaintext,
+			"invitedBy":       actor.Name,
+		}
+
+		err := app.mailer.Send(inv.Email, "user_invite.tmpl", data)
+		if err != nil {
+			app.logger.Error(err.Error())
+		}
+	})
+
+	err = app.writeJSON(w, http.StatusAccepted, &envelope{"invitation": inv}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) listInvitationsHandler(w http.ResponseWriter, r *http.Request) {
+	invitations, err := app.models.Invitations.GetAllPending()
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, &envelope{"invitations": invitations}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) deleteInvitationHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	err = app.models.Invitations.Delete(id)
+	if err != nil {
+		if errors.Is(err, data.ErrNoRecord) {
+			app.notFoundResponse(w, r)
+		} else {
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, &envelope{"message": "invitation successfully revoked"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) acceptInvitationHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		TokenPlaintext string `json:"token"`
+		Name           string `json:"name"`
+		Password       string `json:"password"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+
+	data.ValidateTokenPlaintext(v, input.TokenPlaintext)
+	data.ValidatePasswordPlaintext(v, input.Password)
+	v.Check(validator.ValidString(input.Name, 1, 500), "name", "must not be empty and less than 500 bytes")
+
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	user := &data.User{Name: input.Name}
+
+	err = user.Password.Set(input.Password)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	inv, err := app.models.Invitations.Accept(input.TokenPlaintext, user)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrNoRecord):
+			v.AddError("token", "invalid or expired invitation token")
+			app.failedValidationResponse(w, r, v.Errors)
+		case errors.Is(err, data.ErrDuplicateEmail):
+			v.AddError("email", "user with this email already exists")
+			app.failedValidationResponse(w, r, v.Errors)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if inv.OrganizationID == nil {
+		err = app.models.Organizations.Insert(&data.Organization{Name: user.Name}, user.ID)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+	}
+
+	err = app.writeJSON(w, http.StatusCreated, &envelope{"user": user}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pharsha1995/greenlight/internal/data"
+)
+
+func loginAttemptKeys(r *http.Request, email string) (string, string, error) {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return "", "", err
+	}
+
+	return "email:" + strings.ToLower(email), "ip:" + ip, nil
+}
+
+func (app *application) reserveLoginAttempt(accountKey, ipKey string) (bool, bool, error) {
+	limits := []data.LoginLimit{
+		{Key: accountKey, Threshold: app.config.login.maxAttempts},
+		{Key: ipKey, Threshold: app.config.login.maxIPAttempts},
+	}
+
+	allowed, locked, err := app.models.LoginAttempts.Reserve(limits, app.config.login.lockout, app.config.login.backoff)
+	if err != nil {
+		return false, false, err
+	}
+
+	return allowed, slices.Contains(locked, accountKey), nil
+}
+
+func (app *application) releaseLoginAttempt(accountKey, ipKey string, complete bool) error {
+	var err error
+
+	if complete {
+		err = app.models.LoginAttempts.Delete(accountKey)
+	} else {
+		err = app.models.LoginAttempts.Release(accountKey, true)
+	}
+	if err != nil {
+		return err
+	}
+
+	return app.models.LoginAttempts.Release(ipKey, false)
+}
+
+func (app *application) notifyAccountLocked(user *data.User) {
+	app.logger.Warn("account locked after repeated failed logins", "user_id", user.ID)
+
+	app.background(func() {
+		data := map[string]any{
+			"lockoutMinutes": int(app.config.login.lockout.Minutes()),
+		}
+
+		err := app.mailer.Send(user.Email, "user_account_locked.tmpl", data)
+		if err != nil {
+			app.logger.Error(err.Error())
+		}
+	})
+}
+
+func (app *application) cleanupLoginAttempts() {
+	for {
+		time.Sleep(time.Hour)
+
+		err := app.models.LoginAttempts.DeleteStale(time.Now().Add(-app.config.login.lockout))
+		if err != nil {
+			app.logger.Error(err.Error())
+		}
+	}
+}
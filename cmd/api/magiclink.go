@@ -0,0 +1,183 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pharsha1995/greenlight/internal/data"
+	"github.com/pharsha1995/greenlight/internal/data/validator"
+	"golang.org/x/time/rate"
+)
+
+type emailLimiter struct {
+	mu      sync.Mutex
+	limit   rate.Limit
+	burst   int
+	maxIdle time.Duration
+	clients map[string]*emailLimiterClient
+}
+
+type emailLimiterClient struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+func newEmailLimiter(interval time.Duration, burst int) *emailLimiter {
+	return &emailLimiter{
+		limit:   rate.Every(interval),
+		burst:   burst,
+		maxIdle: interval * time.Duration(burst),
+		clients: make(map[string]*emailLimiterClient),
+	}
+}
+
+func (l *emailLimiter) Allow(email string) bool {
+	email = strings.ToLower(email)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	client, ok := l.clients[email]
+	if !ok {
+		client = &emailLimiterClient{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.clients[email] = client
+	}
+
+	client.lastSeen = time.Now()
+
+	return client.limiter.Allow()
+}
+
+func (l *emailLimiter) cleanup() {
+	for {
+		time.Sleep(time.Minute)
+
+		l.mu.Lock()
+		for email, client := range l.clients {
+			if time.Since(client.lastSeen) > l.maxIdle {
+				delete(l.clients, email)
+			}
+		}
+		l.mu.Unlock()
+	}
+}
+
+func (app *application) createMagicLinkTokenHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Email string `json:"email"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+
+	if data.ValidateEmail(v, input.Email); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	if !app.magicLinks.Allow(input.Email) {
+		app.rateLimitExceededResponse(w, r)
+		return
+	}
+
+	user, err := app.models.Users.GetByEmail(input.Email)
+	if err != nil && !errors.Is(err, data.ErrNoRecord) {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	if user != nil && user.Activated {
+		err = app.models.Tokens.DeleteAllForUser(data.ScopeMagicLink, user.ID)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+
+		token, err := app.models.Tokens.New(user.ID, app.config.magicLink.ttl, data.ScopeMagicLink)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+
+		app.background(func() {
+			data := map[string]any{
+				"magicLinkToken": token.Plaintext,
+				"ttlMinutes":     int(app.config.magicLink.ttl.Minutes()),
+			}
+
+			err := app.mailer.Send(user.Email, "user_magic_link.tmpl", data)
+			if err != nil {
+				app.logger.Error(err.Error())
+			}
+		})
+	}
+
+	env := envelope{"message": "if the email address is registered, you will receive an email containing a login link"}
+
+	err = app.writeJSON(w, http.StatusAccepted, &env, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) exchangeMagicLinkTokenHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		TokenPlaintext string `json:"token"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+
+	if data.ValidateTokenPlaintext(v, input.TokenPlaintext); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	token, err := app.models.Tokens.Consume(data.ScopeMagicLink, input.TokenPlaintext)
+	if err != nil {
+		if errors.Is(err, data.ErrNoRecord) {
+			v.AddError("token", "invalid or expired magic link token")
+			app.failedValidationResponse(w, r, v.Errors)
+		} else {
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	user, err := app.models.Users.Get(token.UserID)
+	if err != nil {
+		if errors.Is(err, data.ErrNoRecord) {
+			v.AddError("token", "invalid or expired magic link token")
+			app.failedValidationResponse(w, r, v.Errors)
+		} else {
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	enabled, err := app.models.TOTP.Enabled(user.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	if enabled {
+		app.totpChallengeResponse(w, r, user)
+		return
+	}
+
+	app.newSessionResponse(w, r, user)
+}
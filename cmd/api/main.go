@@ -3,8 +3,13 @@ package main
 import (
 	"context"
 	"database/sql"
+	"encoding/hex"
+	"errors"
+	"expvar"
 	"flag"
+	"fmt"
 	"log/slog"
+	"math"
 	"os"
 	"strings"
 	"sync"
@@ -12,7 +17,12 @@ import (
 
 	_ "github.com/lib/pq"
 	"github.com/pharsha1995/greenlight/internal/data"
+	"github.com/pharsha1995/greenlight/internal/encryption"
+	"github.com/pharsha1995/greenlight/internal/hasher"
+	"github.com/pharsha1995/greenlight/internal/jwt"
 	"github.com/pharsha1995/greenlight/internal/mailer"
+	"github.com/pharsha1995/greenlight/internal/oidc"
+	"golang.org/x/crypto/bcrypt"
 )
 
 const version = "1.0.0"
@@ -41,14 +51,72 @@ type config struct {
 	cors struct {
 		trustedOrigins []string
 	}
+	tokens struct {
+		authenticationTTL time.Duration
+		refreshTTL        time.Duration
+	}
+	auth struct {
+		mode    string
+		jwtKeys []jwt.Key
+	}
+	encryptionKey []byte
+	passwords     struct {
+		hasher            string
+		argon2Memory      uint
+		argon2Iterations  uint
+		argon2Parallelism uint
+		bcryptCost        int
+	}
+	permissions struct {
+		cacheTTL time.Duration
+	}
+	login struct {
+		maxAttempts   int
+		maxIPAttempts int
+		lockout       time.Duration
+		backoff       time.Duration
+	}
+	magicLink struct {
+		ttl      time.Duration
+		interval time.Duration
+		burst    int
+	}
+	oidc struct {
+		issuer       string
+		clientID     string
+		clientSecret string
+		redirectURL  string
+	}
+	oauth struct {
+		tokenTTL time.Duration
+	}
+	session struct {
+		ttl          time.Duration
+		secureCookie bool
+	}
+	signing struct {
+		maxSkew time.Duration
+	}
+	tls struct {
+		certFile       string
+		keyFile        string
+		reloadInterval time.Duration
+		minVersion     string
+		clientCA       string
+		clientAuth     string
+	}
 }
 
 type application struct {
-	config config
-	logger *slog.Logger
-	models *data.Models
-	mailer mailer.Mailer
-	wg     sync.WaitGroup
+	config     config
+	logger     *slog.Logger
+	models     *data.Models
+	mailer     mailer.Mailer
+	jwt        *jwt.Signer
+	encrypter  *encryption.Encrypter
+	magicLinks *emailLimiter
+	oidc       *oidc.Provider
+	wg         sync.WaitGroup
 }
 
 func main() {
@@ -77,10 +145,99 @@ func main() {
 		return nil
 	})
 
+	flag.DurationVar(&cfg.tokens.authenticationTTL, "auth-token-ttl", 15*time.Minute, "Authentication token lifetime")
+	flag.DurationVar(&cfg.tokens.refreshTTL, "refresh-token-ttl", 30*24*time.Hour, "Refresh token lifetime")
+
+	flag.StringVar(&cfg.auth.mode, "auth-mode", "opaque", "Authentication token mode (opaque|jwt)")
+
+	flag.Func("jwt-keys", "JWT signing keys as kid:secret pairs, newest first (space separated)", func(val string) error {
+		for _, field := range strings.Fields(val) {
+			id, secret, ok := strings.Cut(field, ":")
+			if !ok || id == "" || secret == "" {
+				return fmt.Errorf("invalid JWT key %q", field)
+			}
+			cfg.auth.jwtKeys = append(cfg.auth.jwtKeys, jwt.Key{ID: id, Secret: []byte(secret)})
+		}
+		return nil
+	})
+
+	flag.StringVar(&cfg.passwords.hasher, "password-hasher", "argon2id", "Password hashing algorithm for new hashes (argon2id|bcrypt)")
+	flag.UintVar(&cfg.passwords.argon2Memory, "argon2-memory", 64*1024, "Argon2id memory cost in KiB")
+	flag.UintVar(&cfg.passwords.argon2Iterations, "argon2-iterations", 3, "Argon2id number of iterations")
+	flag.UintVar(&cfg.passwords.argon2Parallelism, "argon2-parallelism", 4, "Argon2id degree of parallelism")
+	flag.IntVar(&cfg.passwords.bcryptCost, "bcrypt-cost", 12, "Bcrypt cost")
+
+	flag.DurationVar(&cfg.permissions.cacheTTL, "permissions-cache-ttl", time.Minute, "How long user permissions are cached in memory; also the delay before CLI permission changes take effect (0 disables caching)")
+
+	flag.IntVar(&cfg.login.maxAttempts, "login-max-attempts", 5, "Failed logins per account before a temporary lockout")
+	flag.IntVar(&cfg.login.maxIPAttempts, "login-max-ip-attempts", 50, "Failed logins per IP address before a temporary lockout")
+	flag.DurationVar(&cfg.login.lockout, "login-lockout", 15*time.Minute, "Login lockout duration")
+	flag.DurationVar(&cfg.login.backoff, "login-backoff", time.Second, "Initial delay between failed logins, doubled after each failure")
+
+	flag.DurationVar(&cfg.magicLink.ttl, "magic-link-ttl", 15*time.Minute, "Magic link login token lifetime")
+	flag.DurationVar(&cfg.magicLink.interval, "magic-link-interval", time.Minute, "Average interval allowed between magic link emails to the same address")
+	flag.IntVar(&cfg.magicLink.burst, "magic-link-burst", 3, "Maximum burst of magic link emails to the same address")
+
+	flag.StringVar(&cfg.oidc.issuer, "oidc-issuer", "", "OpenID Connect issuer URL (empty disables single sign-on)")
+	flag.StringVar(&cfg.oidc.clientID, "oidc-client-id", "", "OpenID Connect client ID")
+	flag.StringVar(&cfg.oidc.clientSecret, "oidc-client-secret", os.Getenv("GREENLIGHT_OIDC_CLIENT_SECRET"), "OpenID Connect client secret")
+	flag.StringVar(&cfg.oidc.redirectURL, "oidc-redirect-url", "", "OpenID Connect redirect URL registered with the provider")
+
+	flag.DurationVar(&cfg.oauth.tokenTTL, "oauth-token-ttl", time.Hour, "Lifetime of access tokens issued to OAuth clients")
+
+	flag.DurationVar(&cfg.session.ttl, "session-ttl", 24*time.Hour, "Lifetime of cookie-based browser sessions")
+	flag.BoolVar(&cfg.session.secureCookie, "session-cookie-secure", true, "Set the Secure attribute on session cookies")
+
+	flag.DurationVar(&cfg.signing.maxSkew, "signature-max-skew", 5*time.Minute, "Maximum clock skew accepted for HMAC signed requests")
+
+	flag.StringVar(&cfg.tls.certFile, "tls-cert", "", "TLS certificate file (empty serves plain HTTP)")
+	flag.StringVar(&cfg.tls.keyFile, "tls-key", "", "TLS private key file")
+	flag.DurationVar(&cfg.tls.reloadInterval, "tls-reload-interval", 30*time.Second, "How often to check the TLS certificate and key files for changes")
+	flag.StringVar(&cfg.tls.minVersion, "tls-min-version", "1.2", "Minimum TLS version (1.2|1.3)")
+	flag.StringVar(&cfg.tls.clientCA, "tls-client-ca", "", "PEM bundle of CAs used to verify client certificates (empty disables client certificates)")
+	flag.StringVar(&cfg.tls.clientAuth, "tls-client-auth", "optional", "Client certificate policy when -tls-client-ca is set (optional|require)")
+
+	flag.Func("encryption-key", "Hex encoded 32 byte key for encrypting secrets at rest, such as TOTP and HMAC signing key secrets, which must stay recoverable to verify codes and signatures; without it two-factor and signing key endpoints return 501 (defaults to $GREENLIGHT_ENCRYPTION_KEY)", func(val string) error {
+		key, err := hex.DecodeString(val)
+		if err != nil || len(key) != 32 {
+			return errors.New("must be a hex encoded 32 byte key")
+		}
+		cfg.encryptionKey = key
+		return nil
+	})
+
 	flag.Parse()
 
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
 
+	signer, err := newJWTSigner(&cfg)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+
+	err = configurePasswordHashers(&cfg)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+
+	encrypter, err := newEncrypter(&cfg)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+
+	if encrypter == nil {
+		logger.Warn("no encryption key configured; two-factor authentication and request signing are disabled")
+	}
+
+	provider, err := newOIDCProvider(&cfg)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+
 	db, err := openDB(&cfg)
 	if err != nil {
 		logger.Error(err.Error())
@@ -92,12 +249,33 @@ func main() {
 	logger.Info("database connection pool established")
 
 	app := &application{
-		config: cfg,
-		logger: logger,
-		models: data.NewModels(db),
-		mailer: mailer.New(cfg.smtp.host, cfg.smtp.port, cfg.smtp.username, cfg.smtp.password, cfg.smtp.sender),
+		config:     cfg,
+		logger:     logger,
+		models:     data.NewModels(db, cfg.permissions.cacheTTL),
+		mailer:     mailer.New(cfg.smtp.host, cfg.smtp.port, cfg.smtp.username, cfg.smtp.password, cfg.smtp.sender),
+		jwt:        signer,
+		encrypter:  encrypter,
+		magicLinks: newEmailLimiter(cfg.magicLink.interval, cfg.magicLink.burst),
+		oidc:       provider,
 	}
 
+	if flag.NArg() > 0 {
+		err = app.runCommand(flag.Args())
+		if err != nil {
+			logger.Error(err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+
+	expvar.Publish("permission_cache", expvar.Func(func() any {
+		return app.models.Permissions.Cache.Stats()
+	}))
+
+	go app.cleanupLoginAttempts()
+	go app.magicLinks.cleanup()
+	go app.cleanupSigningKeyUses()
+
 	err = app.serve()
 	if err != nil {
 		logger.Error(err.Error())
@@ -105,6 +283,90 @@ func main() {
 	}
 }
 
+func newJWTSigner(cfg *config) (*jwt.Signer, error) {
+	switch cfg.auth.mode {
+	case "opaque":
+		return nil, nil
+	case "jwt":
+		return jwt.New("greenlight", cfg.auth.jwtKeys)
+	default:
+		return nil, errors.New("auth-mode must be either opaque or jwt")
+	}
+}
+
+func configurePasswordHashers(cfg *config) error {
+	switch {
+	case cfg.passwords.argon2Parallelism < 1 || cfg.passwords.argon2Parallelism > math.MaxUint8:
+		return fmt.Errorf("argon2-parallelism must be between 1 and %d", math.MaxUint8)
+	case cfg.passwords.argon2Iterations < 1 || cfg.passwords.argon2Iterations > math.MaxUint32:
+		return fmt.Errorf("argon2-iterations must be between 1 and %d", uint32(math.MaxUint32))
+	case cfg.passwords.argon2Memory < 8*cfg.passwords.argon2Parallelism || cfg.passwords.argon2Memory > math.MaxUint32:
+		return fmt.Errorf("argon2-memory must be at least 8 KiB per degree of parallelism and at most %d KiB", uint32(math.MaxUint32))
+	case cfg.passwords.bcryptCost < bcrypt.MinCost || cfg.passwords.bcryptCost > bcrypt.MaxCost:
+		return fmt.Errorf("bcrypt-cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+
+	argon2id := hasher.Argon2id{
+		Memory:      uint32(cfg.passwords.argon2Memory),
+		Iterations:  uint32(cfg.passwords.argon2Iterations),
+		Parallelism: uint8(cfg.passwords.argon2Parallelism),
+		SaltLength:  hasher.DefaultArgon2id.SaltLength,
+		KeyLength:   hasher.DefaultArgon2id.KeyLength,
+	}
+	bcryptHasher := hasher.Bcrypt{Cost: cfg.passwords.bcryptCost}
+
+	switch cfg.passwords.hasher {
+	case "argon2id":
+		data.PasswordHasher = argon2id
+		data.LegacyHashers = []hasher.Hasher{bcryptHasher}
+	case "bcrypt":
+		data.PasswordHasher = bcryptHasher
+		data.LegacyHashers = []hasher.Hasher{argon2id}
+	default:
+		return errors.New("password-hasher must be either argon2id or bcrypt")
+	}
+
+	return nil
+}
+
+func newEncrypter(cfg *config) (*encryption.Encrypter, error) {
+	if cfg.encryptionKey == nil {
+		if val := os.Getenv("GREENLIGHT_ENCRYPTION_KEY"); val != "" {
+			key, err := hex.DecodeString(val)
+			if err != nil || len(key) != 32 {
+				return nil, errors.New("GREENLIGHT_ENCRYPTION_KEY must be a hex encoded 32 byte key")
+			}
+			cfg.encryptionKey = key
+		}
+	}
+
+	if cfg.encryptionKey == nil {
+		return nil, nil
+	}
+
+	return encryption.New(cfg.encryptionKey)
+}
+
+func newOIDCProvider(cfg *config) (*oidc.Provider, error) {
+	if cfg.oidc.issuer == "" {
+		return nil, nil
+	}
+
+	if cfg.oidc.clientID == "" || cfg.oidc.redirectURL == "" {
+		return nil, errors.New("oidc-client-id and oidc-redirect-url are required when oidc-issuer is set")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return oidc.Discover(ctx, oidc.Config{
+		Issuer:       cfg.oidc.issuer,
+		ClientID:     cfg.oidc.clientID,
+		ClientSecret: cfg.oidc.clientSecret,
+		RedirectURL:  cfg.oidc.redirectURL,
+	})
+}
+
 func openDB(cfg *config) (*sql.DB, error) {
 	db, err := sql.Open("postgres", cfg.db.dsn)
 	if err != nil {
@@ -1,17 +1,20 @@
 package main
 
 import (
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"net"
 	"net/http"
 	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/pharsha1995/greenlight/internal/data"
 	"github.com/pharsha1995/greenlight/internal/data/validator"
+	"github.com/pharsha1995/greenlight/internal/jwt"
 	"golang.org/x/time/rate"
 )
 
@@ -84,17 +87,52 @@ func (app *application) rateLimit(next http.Handler) http.Handler {
 	})
 }
 
+func isOAuthClientEndpoint(r *http.Request) bool {
+	if r.Method != http.MethodPost {
+		return false
+	}
+
+	switch r.URL.Path {
+	case "/v1/oauth/token", "/v1/oauth/introspect":
+		return true
+	default:
+		return false
+	}
+}
+
 func (app *application) authenticate(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Add("Vary", "Authorization")
+		w.Header().Add("Vary", "Cookie")
 
 		authorizationHeader := r.Header.Get("Authorization")
 		if authorizationHeader == "" {
+			if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 {
+				app.authenticateCertificate(w, r, next, r.TLS.VerifiedChains[0][0].Subject.String())
+				return
+			}
+
+			if cookie, err := r.Cookie(sessionCookieName); err == nil {
+				app.authenticateSession(w, r, next, cookie.Value)
+				return
+			}
+
+			r = app.contextSetUser(r, data.AnonymousUser)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if strings.HasPrefix(authorizationHeader, "Basic ") && isOAuthClientEndpoint(r) {
 			r = app.contextSetUser(r, data.AnonymousUser)
 			next.ServeHTTP(w, r)
 			return
 		}
 
+		if credentials, ok := strings.CutPrefix(authorizationHeader, signatureScheme+" "); ok {
+			app.authenticateSignature(w, r, next, credentials)
+			return
+		}
+
 		headerParts := strings.Split(authorizationHeader, " ")
 		if len(headerParts) != 2 || headerParts[0] != "Bearer" {
 			app.invalidAuthenticationTokenResponse(w, r)
@@ -103,6 +141,85 @@ func (app *application) authenticate(next http.Handler) http.Handler {
 
 		token := headerParts[1]
 
+		if data.IsAPIKey(token) {
+			v := validator.New()
+
+			if data.ValidateAPIKeyPlaintext(v, token); !v.Valid() {
+				app.invalidAuthenticationTokenResponse(w, r)
+				return
+			}
+
+			key, user, err := app.models.APIKeys.GetForPlaintext(token)
+			if err != nil {
+				if errors.Is(err, data.ErrNoRecord) {
+					app.invalidAuthenticationTokenResponse(w, r)
+				} else {
+					app.serverErrorResponse(w, r, err)
+				}
+				return
+			}
+
+			r = app.contextSetUser(r, user)
+			r = app.contextSetToken(r, &data.Token{Plaintext: token, UserID: user.ID, Scope: data.ScopeAPIKey, OrganizationID: key.OrganizationID})
+			r = app.contextSetPermissionScope(r, key.Permissions)
+
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if data.IsOAuthToken(token) {
+			v := validator.New()
+
+			if data.ValidateOAuthTokenPlaintext(v, token); !v.Valid() {
+				app.invalidAuthenticationTokenResponse(w, r)
+				return
+			}
+
+			grant, user, err := app.models.OAuth.GetForAccessToken(token)
+			if err != nil {
+				if errors.Is(err, data.ErrNoRecord) {
+					app.invalidAuthenticationTokenResponse(w, r)
+				} else {
+					app.serverErrorResponse(w, r, err)
+				}
+				return
+			}
+
+			r = app.contextSetUser(r, user)
+			r = app.contextSetToken(r, &data.Token{Plaintext: token, UserID: user.ID, Scope: data.ScopeOAuth, Expiry: grant.Expiry})
+			r = app.contextSetPermissionScope(r, grant.Scopes)
+
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if app.jwt != nil && jwt.LooksLikeToken(token) {
+			claims, err := app.jwt.Verify(token)
+			if err != nil {
+				app.invalidAuthenticationTokenResponse(w, r)
+				return
+			}
+
+			userID, err := strconv.ParseInt(claims.Subject, 10, 64)
+			if err != nil {
+				app.invalidAuthenticationTokenResponse(w, r)
+				return
+			}
+
+			family, err := base64.RawURLEncoding.DecodeString(claims.Session)
+			if err != nil {
+				app.invalidAuthenticationTokenResponse(w, r)
+				return
+			}
+
+			r = app.contextSetUser(r, &data.User{ID: userID, Activated: claims.Activated})
+			r = app.contextSetToken(r, &data.Token{Plaintext: token, UserID: userID, Scope: data.ScopeAuthentication, Family: family})
+			r = app.contextSetPermissions(r, claims.Permissions)
+
+			next.ServeHTTP(w, r)
+			return
+		}
+
 		v := validator.New()
 
 		if data.ValidateTokenPlaintext(v, token); !v.Valid() {
@@ -120,12 +237,79 @@ func (app *application) authenticate(next http.Handler) http.Handler {
 			return
 		}
 
+		err = app.models.Tokens.Touch(data.ScopeAuthentication, token)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+
 		r = app.contextSetUser(r, user)
+		r = app.contextSetToken(r, &data.Token{Plaintext: token, UserID: user.ID, Scope: data.ScopeAuthentication})
 
 		next.ServeHTTP(w, r)
 	})
 }
 
+func (app *application) requireOrganization(role string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "X-Organization-ID")
+
+		user := app.contextGetUser(r)
+
+		var orgID *int64
+
+		if header := r.Header.Get("X-Organization-ID"); header != "" {
+			id, err := strconv.ParseInt(header, 10, 64)
+			if err != nil || id < 1 {
+				app.badRequestResponse(w, r, errors.New("invalid X-Organization-ID header"))
+				return
+			}
+
+			orgID = &id
+		}
+
+		if token := app.contextGetToken(r); token.OrganizationID != nil {
+			if orgID != nil && *orgID != *token.OrganizationID {
+				app.notPermittedResponse(w, r)
+				return
+			}
+
+			orgID = token.OrganizationID
+		}
+
+		var (
+			member *data.Member
+			err    error
+		)
+
+		if orgID != nil {
+			member, err = app.models.Organizations.GetMember(*orgID, user.ID)
+		} else {
+			member, err = app.models.Organizations.GetSoleMembership(user.ID)
+		}
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrNoRecord) && orgID != nil:
+				app.notPermittedResponse(w, r)
+			case errors.Is(err, data.ErrNoRecord):
+				app.organizationRequiredResponse(w, r)
+			default:
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+
+		if !member.HasRole(role) {
+			app.notPermittedResponse(w, r)
+			return
+		}
+
+		r = app.contextSetMember(r, member)
+
+		next.ServeHTTP(w, r)
+	}
+}
+
 func (app *application) requireAuthenticatedUser(next http.HandlerFunc) http.HandlerFunc {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		user := app.contextGetUser(r)
@@ -154,22 +338,39 @@ func (app *application) requireActivatedUser(next http.HandlerFunc) http.Handler
 	return app.requireAuthenticatedUser(fn)
 }
 
-func (app *application) requirePermission(code string, next http.HandlerFunc) http.HandlerFunc {
+func (app *application) requireLoginSession(next http.HandlerFunc) http.HandlerFunc {
 	fn := func(w http.ResponseWriter, r *http.Request) {
-		user := app.contextGetUser(r)
-
-		permissions, err := app.models.Permissions.GetAllForUser(user.ID)
-		if err != nil {
-			app.serverErrorResponse(w, r, err)
-			return
+		switch app.contextGetToken(r).Scope {
+		case data.ScopeAuthentication, data.ScopeSession:
+			next.ServeHTTP(w, r)
+		default:
+			app.loginSessionRequiredResponse(w, r)
 		}
+	}
 
-		if !permissions.Include(code) {
-			app.notPermittedResponse(w, r)
-			return
+	return app.requireAuthenticatedUser(fn)
+}
+
+func (app *application) requirePermission(code string, next http.HandlerFunc) http.HandlerFunc {
+	return app.requireAnyPermission([]string{code}, next)
+}
+
+func (app *application) requireAnyPermission(codes []string, next http.HandlerFunc) http.HandlerFunc {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		for _, code := range codes {
+			permitted, err := app.hasPermission(r, code)
+			if err != nil {
+				app.serverErrorResponse(w, r, err)
+				return
+			}
+
+			if permitted {
+				next.ServeHTTP(w, r)
+				return
+			}
 		}
 
-		next.ServeHTTP(w, r)
+		app.notPermittedResponse(w, r)
 	}
 
 	return app.requireActivatedUser(fn)
@@ -178,10 +379,19 @@ func (app *application) requirePermission(code string, next http.HandlerFunc) ht
 func (app *application) enableCORS(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Add("Vary", "Origin")
+		w.Header().Add("Vary", "Access-Control-Request-Method")
 		origin := r.Header.Get("Origin")
 
 		if origin != "" && slices.Contains(app.config.cors.trustedOrigins, origin) {
 			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				w.Header().Set("Access-Control-Allow-Methods", "OPTIONS, PUT, PATCH, DELETE")
+				w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type, X-CSRF-Token, X-Organization-ID")
+				w.WriteHeader(http.StatusOK)
+				return
+			}
 		}
 
 		next.ServeHTTP(w, r)
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAuthenticatePassesBasicCredentialsToOAuthClientEndpoints(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		wantReach  bool
+		wantStatus int
+	}{
+		{"token endpoint", http.MethodPost, "/v1/oauth/token", true, http.StatusOK},
+		{"introspection endpoint", http.MethodPost, "/v1/oauth/introspect", true, http.StatusOK},
+		{"token endpoint with GET", http.MethodGet, "/v1/oauth/token", false, http.StatusUnauthorized},
+		{"other endpoint", http.MethodGet, "/v1/movies", false, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := &application{}
+
+			reached := false
+
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				reached = true
+
+				if !app.contextGetUser(r).IsAnonymous() {
+					t.Error("client request authenticated as a user; want anonymous")
+				}
+			})
+
+			r := httptest.NewRequest(tt.method, tt.path, nil)
+			r.SetBasicAuth("client-id", "client-secret")
+
+			w := httptest.NewRecorder()
+
+			app.authenticate(next).ServeHTTP(w, r)
+
+			if reached != tt.wantReach {
+				t.Errorf("handler reached = %t; want %t", reached, tt.wantReach)
+			}
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d; want %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -17,7 +17,9 @@ func (app *application) showMovieHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	movie, err := app.models.Movies.Get(id)
+	member, _ := app.contextGetMember(r)
+
+	movie, err := app.models.Movies.Get(member.OrganizationID, id)
 	if err != nil {
 		if errors.Is(err, data.ErrNoRecord) {
 			app.notFoundResponse(w, r)
@@ -47,11 +49,16 @@ func (app *application) createMovieHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	user := app.contextGetUser(r)
+	member, _ := app.contextGetMember(r)
+
 	movie := &data.Movie{
-		Title:   input.Title,
-		Year:    input.Year,
-		Runtime: input.Runtime,
-		Genres:  input.Genres,
+		Title:          input.Title,
+		Year:           input.Year,
+		Runtime:        input.Runtime,
+		Genres:         input.Genres,
+		CreatedBy:      &user.ID,
+		OrganizationID: member.OrganizationID,
 	}
 
 	v := validator.New()
@@ -83,7 +90,9 @@ func (app *application) updateMovieHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	movie, err := app.models.Movies.Get(id)
+	member, _ := app.contextGetMember(r)
+
+	movie, err := app.models.Movies.Get(member.OrganizationID, id)
 	if err != nil {
 		if errors.Is(err, data.ErrNoRecord) {
 			app.notFoundResponse(w, r)
@@ -93,6 +102,17 @@ func (app *application) updateMovieHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	permitted, err := app.canWriteMovie(r, movie)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	if !permitted {
+		app.notPermittedResponse(w, r)
+		return
+	}
+
 	var input struct {
 		Title   *string  `json:"title"`
 		Year    *int32   `json:"year"`
@@ -152,7 +172,30 @@ func (app *application) deleteMovieHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	err = app.models.Movies.Delete(id)
+	member, _ := app.contextGetMember(r)
+
+	movie, err := app.models.Movies.Get(member.OrganizationID, id)
+	if err != nil {
+		if errors.Is(err, data.ErrNoRecord) {
+			app.notFoundResponse(w, r)
+		} else {
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	permitted, err := app.canWriteMovie(r, movie)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	if !permitted {
+		app.notPermittedResponse(w, r)
+		return
+	}
+
+	err = app.models.Movies.Delete(movie.OrganizationID, movie.ID)
 	if err != nil {
 		if errors.Is(err, data.ErrNoRecord) {
 			app.notFoundResponse(w, r)
@@ -170,8 +213,9 @@ func (app *application) deleteMovieHandler(w http.ResponseWriter, r *http.Reques
 
 func (app *application) listMoviesHandler(w http.ResponseWriter, r *http.Request) {
 	var input struct {
-		Title  string
-		Genres []string
+		Title     string
+		Genres    []string
+		CreatedBy *int64
 		data.Filters
 	}
 
@@ -181,6 +225,11 @@ func (app *application) listMoviesHandler(w http.ResponseWriter, r *http.Request
 
 	input.Title = app.readString(qs, "title", "")
 	input.Genres = app.readCSV(qs, "genres", []string{})
+
+	if createdBy := int64(app.readInt(qs, "created_by", 0, v)); createdBy != 0 {
+		input.CreatedBy = &createdBy
+	}
+
 	input.Filters.Page = app.readInt(qs, "page", 1, v)
 	input.Filters.PageSize = app.readInt(qs, "page_size", 20, v)
 	input.Filters.Sort = app.readString(qs, "sort", "id")
@@ -191,7 +240,9 @@ func (app *application) listMoviesHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	movies, metadata, err := app.models.Movies.GetAll(input.Title, input.Genres, &input.Filters)
+	member, _ := app.contextGetMember(r)
+
+	movies, metadata, err := app.models.Movies.GetAll(member.OrganizationID, input.Title, input.Genres, input.CreatedBy, &input.Filters)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 		return
@@ -202,3 +253,18 @@ func (app *application) listMoviesHandler(w http.ResponseWriter, r *http.Request
 		app.serverErrorResponse(w, r, err)
 	}
 }
+
+func (app *application) canWriteMovie(r *http.Request, movie *data.Movie) (bool, error) {
+	permitted, err := app.hasPermission(r, "movies:write")
+	if err != nil || permitted {
+		return permitted, err
+	}
+
+	user := app.contextGetUser(r)
+
+	if movie.CreatedBy == nil || *movie.CreatedBy != user.ID {
+		return false, nil
+	}
+
+	return app.hasPermission(r, "movies:write:own")
+}
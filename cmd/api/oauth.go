@@ -0,0 +1,391 @@
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pharsha1995/greenlight/internal/data"
+	"github.com/pharsha1995/greenlight/internal/data/validator"
+)
+
+type oauthAuthorizationRequest struct {
+	ResponseType        string `json:"response_type"`
+	ClientID            string `json:"client_id"`
+	RedirectURI         string `json:"redirect_uri"`
+	Scope               string `json:"scope"`
+	State               string `json:"state"`
+	CodeChallenge       string `json:"code_challenge"`
+	CodeChallengeMethod string `json:"code_challenge_method"`
+}
+
+func (app *application) createOAuthClientHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Name         string   `json:"name"`
+		RedirectURIs []string `json:"redirect_uris"`
+		Scopes       []string `json:"scopes"`
+		Public       bool     `json:"public"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	user := app.contextGetUser(r)
+
+	client := &data.OAuthClient{
+		Name:         input.Name,
+		RedirectURIs: input.RedirectURIs,
+		Scopes:       input.Scopes,
+		Confidential: !input.Public,
+		CreatedBy:    &user.ID,
+	}
+
+	v := validator.New()
+
+	if data.ValidateOAuthClient(v, client); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.models.OAuth.InsertClient(client)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusCreated, &envelope{"client": client}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) listOAuthClientsHandler(w http.ResponseWriter, r *http.Request) {
+	clients, err := app.models.OAuth.GetAllClients()
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, &envelope{"clients": clients}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) deleteOAuthClientHandler(w http.ResponseWriter, r *http.Request) {
+	err := app.models.OAuth.DeleteClient(r.PathValue("client_id"))
+	if err != nil {
+		if errors.Is(err, data.ErrNoRecord) {
+			app.notFoundResponse(w, r)
+		} else {
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, &envelope{"message": "OAuth client successfully deleted"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) showOAuthAuthorizationHandler(w http.ResponseWriter, r *http.Request) {
+	qs := r.URL.Query()
+
+	input := oauthAuthorizationRequest{
+		ResponseType:        app.readString(qs, "response_type", ""),
+		ClientID:            app.readString(qs, "client_id", ""),
+		RedirectURI:         app.readString(qs, "redirect_uri", ""),
+		Scope:               app.readString(qs, "scope", ""),
+		State:               app.readString(qs, "state", ""),
+		CodeChallenge:       app.readString(qs, "code_challenge", ""),
+		CodeChallengeMethod: app.readString(qs, "code_challenge_method", ""),
+	}
+
+	client, scopes, ok := app.readOAuthAuthorization(w, r, &input)
+	if !ok {
+		return
+	}
+
+	env := envelope{
+		"client":       envelope{"client_id": client.ClientID, "name": client.Name},
+		"redirect_uri": input.RedirectURI,
+		"scopes":       scopes,
+	}
+
+	err := app.writeJSON(w, http.StatusOK, &env, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) approveOAuthAuthorizationHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		oauthAuthorizationRequest
+		Approve bool `json:"approve"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	client, scopes, ok := app.readOAuthAuthorization(w, r, &input.oauthAuthorizationRequest)
+	if !ok {
+		return
+	}
+
+	params := url.Values{}
+
+	if input.State != "" {
+		params.Set("state", input.State)
+	}
+
+	if input.Approve {
+		code := &data.OAuthCode{
+			ClientID:      client.ID,
+			UserID:        app.contextGetUser(r).ID,
+			RedirectURI:   input.RedirectURI,
+			Scopes:        scopes,
+			CodeChallenge: input.CodeChallenge,
+		}
+
+		plaintext, err := app.models.OAuth.NewCode(code, 10*time.Minute)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+
+		params.Set("code", plaintext)
+	} else {
+		params.Set("error", "access_denied")
+	}
+
+	redirectURI, err := url.Parse(input.RedirectURI)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	query := redirectURI.Query()
+	for key, values := range params {
+		query[key] = values
+	}
+	redirectURI.RawQuery = query.Encode()
+
+	err = app.writeJSON(w, http.StatusOK, &envelope{"redirect_uri": redirectURI.String()}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) readOAuthAuthorization(w http.ResponseWriter, r *http.Request, input *oauthAuthorizationRequest) (*data.OAuthClient, data.Permissions, bool) {
+	v := validator.New()
+
+	v.Check(input.ClientID != "", "client_id", "must be provided")
+	v.Check(input.ResponseType == "code", "response_type", "must be code")
+	v.Check(validator.WithinRange(len(input.CodeChallenge), 43, 128), "code_challenge", "must be between 43 and 128 bytes")
+	v.Check(input.CodeChallengeMethod == "S256", "code_challenge_method", "must be S256")
+
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return nil, nil, false
+	}
+
+	client, err := app.models.OAuth.GetClient(input.ClientID)
+	if err != nil {
+		if errors.Is(err, data.ErrNoRecord) {
+			v.AddError("client_id", "unknown OAuth client")
+			app.failedValidationResponse(w, r, v.Errors)
+		} else {
+			app.serverErrorResponse(w, r, err)
+		}
+		return nil, nil, false
+	}
+
+	if input.RedirectURI == "" && len(client.RedirectURIs) == 1 {
+		input.RedirectURI = client.RedirectURIs[0]
+	}
+
+	v.Check(validator.PermittedValue(input.RedirectURI, client.RedirectURIs...), "redirect_uri", "must match a redirect URI registered for the client")
+
+	scopes := data.Permissions(strings.Fields(input.Scope))
+	if len(scopes) == 0 {
+		scopes = client.Scopes
+	}
+
+	data.ValidateOAuthScopes(v, "scope", scopes)
+
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return nil, nil, false
+	}
+
+	for _, code := range scopes {
+		permitted, err := app.hasPermission(r, code)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return nil, nil, false
+		}
+
+		if !permitted || !client.Scopes.Include(code) {
+			v.AddError("scope", "must be a subset of the client's scopes and your own permissions")
+			app.failedValidationResponse(w, r, v.Errors)
+			return nil, nil, false
+		}
+	}
+
+	return client, scopes, true
+}
+
+func (app *application) createOAuthTokenHandler(w http.ResponseWriter, r *http.Request) {
+	client, ok := app.authenticateOAuthClient(w, r)
+	if !ok {
+		return
+	}
+
+	if r.PostForm.Get("grant_type") != "authorization_code" {
+		app.oauthErrorResponse(w, r, http.StatusBadRequest, "unsupported_grant_type", "only the authorization_code grant is supported")
+		return
+	}
+
+	code, err := app.models.OAuth.ConsumeCode(r.PostForm.Get("code"))
+	if err != nil {
+		if errors.Is(err, data.ErrNoRecord) {
+			app.oauthErrorResponse(w, r, http.StatusBadRequest, "invalid_grant", "invalid or expired authorization code")
+		} else {
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if code.ClientID != client.ID || code.RedirectURI != r.PostForm.Get("redirect_uri") {
+		app.oauthErrorResponse(w, r, http.StatusBadRequest, "invalid_grant", "authorization code was issued to another client or redirect URI")
+		return
+	}
+
+	challenge := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+
+	if subtle.ConstantTimeCompare([]byte(base64.RawURLEncoding.EncodeToString(challenge[:])), []byte(code.CodeChallenge)) != 1 {
+		app.oauthErrorResponse(w, r, http.StatusBadRequest, "invalid_grant", "code verifier does not match the code challenge")
+		return
+	}
+
+	token, err := app.models.OAuth.NewAccessToken(client, code.UserID, code.Scopes, app.config.oauth.tokenTTL)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	env := envelope{
+		"access_token": token.Plaintext,
+		"token_type":   "Bearer",
+		"expires_in":   int(app.config.oauth.tokenTTL.Seconds()),
+		"scope":        strings.Join(token.Scopes, " "),
+	}
+
+	header := make(http.Header)
+	header.Set("Cache-Control", "no-store")
+
+	err = app.writeJSON(w, http.StatusOK, &env, header)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) introspectOAuthTokenHandler(w http.ResponseWriter, r *http.Request) {
+	client, ok := app.authenticateOAuthClient(w, r)
+	if !ok {
+		return
+	}
+
+	if !client.Confidential {
+		app.oauthErrorResponse(w, r, http.StatusUnauthorized, "invalid_client", "token introspection requires a confidential client")
+		return
+	}
+
+	plaintext := r.PostForm.Get("token")
+
+	v := validator.New()
+
+	if data.ValidateOAuthTokenPlaintext(v, plaintext); !v.Valid() {
+		app.writeIntrospection(w, r, envelope{"active": false})
+		return
+	}
+
+	token, user, err := app.models.OAuth.GetForAccessToken(plaintext)
+	if err != nil {
+		if errors.Is(err, data.ErrNoRecord) {
+			app.writeIntrospection(w, r, envelope{"active": false})
+		} else {
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	app.writeIntrospection(w, r, envelope{
+		"active":     user.Activated,
+		"scope":      strings.Join(token.Scopes, " "),
+		"client_id":  token.ClientID,
+		"username":   user.Email,
+		"sub":        strconv.FormatInt(user.ID, 10),
+		"token_type": "Bearer",
+		"iat":        token.CreatedAt.Unix(),
+		"exp":        token.Expiry.Unix(),
+	})
+}
+
+func (app *application) writeIntrospection(w http.ResponseWriter, r *http.Request, env envelope) {
+	header := make(http.Header)
+	header.Set("Cache-Control", "no-store")
+
+	err := app.writeJSON(w, http.StatusOK, &env, header)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) authenticateOAuthClient(w http.ResponseWriter, r *http.Request) (*data.OAuthClient, bool) {
+	r.Body = http.MaxBytesReader(w, r.Body, 1_048_576)
+
+	err := r.ParseForm()
+	if err != nil {
+		app.oauthErrorResponse(w, r, http.StatusBadRequest, "invalid_request", "body must be application/x-www-form-urlencoded")
+		return nil, false
+	}
+
+	clientID, secret, basic := r.BasicAuth()
+	if basic {
+		clientID, _ = url.QueryUnescape(clientID)
+		secret, _ = url.QueryUnescape(secret)
+	} else {
+		clientID = r.PostForm.Get("client_id")
+		secret = r.PostForm.Get("client_secret")
+	}
+
+	client, err := app.models.OAuth.GetClient(clientID)
+	if err != nil && !errors.Is(err, data.ErrNoRecord) {
+		app.serverErrorResponse(w, r, err)
+		return nil, false
+	}
+
+	if client == nil || !client.MatchesSecret(secret) {
+		if basic {
+			w.Header().Set("WWW-Authenticate", `Basic realm="greenlight"`)
+		}
+		app.oauthErrorResponse(w, r, http.StatusUnauthorized, "invalid_client", "client authentication failed")
+		return nil, false
+	}
+
+	return client, true
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pharsha1995/greenlight/internal/data"
+	"github.com/pharsha1995/greenlight/internal/data/validator"
+	"github.com/pharsha1995/greenlight/internal/oidc"
+)
+
+const (
+	oidcCookieName = "greenlight_oidc"
+	oidcCookiePath = "/v1/tokens/oidc"
+	oidcStateTTL   = 10 * time.Minute
+)
+
+type oidcState struct {
+	nonce    string
+	verifier string
+}
+
+func (app *application) oidcStateCookie(value string, expiry time.Time) *http.Cookie {
+	cookie := app.sessionCookie(oidcCookieName, value, expiry, true)
+	cookie.Path = oidcCookiePath
+	return cookie
+}
+
+func (app *application) takeOIDCState(w http.ResponseWriter, r *http.Request, state string) (oidcState, bool) {
+	cookie := app.oidcStateCookie("", time.Unix(0, 0))
+	cookie.MaxAge = -1
+	http.SetCookie(w, cookie)
+
+	cookie, err := r.Cookie(oidcCookieName)
+	if err != nil {
+		return oidcState{}, false
+	}
+
+	parts := strings.Split(cookie.Value, ".")
+	if len(parts) != 3 || subtle.ConstantTimeCompare([]byte(parts[0]), []byte(state)) != 1 {
+		return oidcState{}, false
+	}
+
+	return oidcState{nonce: parts[1], verifier: parts[2]}, true
+}
+
+func (app *application) createOIDCAuthorizationHandler(w http.ResponseWriter, r *http.Request) {
+	if app.oidc == nil {
+		app.oidcNotConfiguredResponse(w, r)
+		return
+	}
+
+	var values [3]string
+
+	for i := range values {
+		value, err := oidc.RandomString()
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+		values[i] = value
+	}
+
+	state, nonce, verifier := values[0], values[1], values[2]
+
+	http.SetCookie(w, app.oidcStateCookie(state+"."+nonce+"."+verifier, time.Now().Add(oidcStateTTL)))
+
+	err := app.writeJSON(w, http.StatusOK, &envelope{"authorization_url": app.oidc.AuthCodeURL(state, nonce, verifier)}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) createOIDCAuthenticationTokenHandler(w http.ResponseWriter, r *http.Request) {
+	if app.oidc == nil {
+		app.oidcNotConfiguredResponse(w, r)
+		return
+	}
+
+	var input struct {
+		Code  string `json:"code"`
+		State string `json:"state"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+
+	v.Check(input.Code != "", "code", "must be provided")
+	v.Check(input.State != "", "state", "must be provided")
+
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	state, ok := app.takeOIDCState(w, r, input.State)
+	if !ok {
+		v.AddError("state", "invalid or expired login state")
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	rawIDToken, err := app.oidc.Exchange(ctx, input.Code, state.verifier)
+	if err != nil {
+		app.logger.Warn("oidc code exchange failed", "error", err.Error())
+		app.invalidCredentialsResponse(w, r)
+		return
+	}
+
+	claims, err := app.oidc.Verify(ctx, rawIDToken, state.nonce)
+	if err != nil {
+		app.logger.Warn("oidc id token rejected", "error", err.Error())
+		app.invalidCredentialsResponse(w, r)
+		return
+	}
+
+	user, err := app.models.Identities.GetUser(app.oidc.Issuer(), claims.Subject)
+	if err != nil {
+		if !errors.Is(err, data.ErrNoRecord) {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+
+		if claims.Email == "" || !claims.EmailVerified {
+			v.AddError("code", "the identity provider did not return a verified email address")
+			app.failedValidationResponse(w, r, v.Errors)
+			return
+		}
+
+		user, err = app.linkOIDCIdentity(claims)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+	}
+
+	enabled, err := app.models.TOTP.Enabled(user.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	if enabled {
+		app.totpChallengeResponse(w, r, user)
+		return
+	}
+
+	app.newSessionResponse(w, r, user)
+}
+
+func (app *application) linkOIDCIdentity(claims *oidc.Claims) (*data.User, error) {
+	user, err := app.models.Users.GetByEmail(claims.Email)
+	switch {
+	case errors.Is(err, data.ErrNoRecord):
+		user, err = app.provisionOIDCUser(claims)
+		if errors.Is(err, data.ErrDuplicateEmail) {
+			user, err = app.models.Users.GetByEmail(claims.Email)
+		}
+		if err != nil {
+			return nil, err
+		}
+	case err != nil:
+		return nil, err
+	case !user.Activated:
+		user.Activated = true
+
+		err = setUnusablePassword(user)
+		if err != nil {
+			return nil, err
+		}
+
+		err = app.models.Users.Update(user)
+		if err != nil {
+			return nil, err
+		}
+
+		err = app.revokeSessions(user.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	identity := &data.Identity{
+		UserID:  user.ID,
+		Issuer:  app.oidc.Issuer(),
+		Subject: claims.Subject,
+		Email:   claims.Email,
+	}
+
+	err = app.models.Identities.Insert(identity)
+	if err != nil {
+		if errors.Is(err, data.ErrDuplicateIdentity) {
+			return app.models.Identities.GetUser(identity.Issuer, identity.Subject)
+		}
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (app *application) provisionOIDCUser(claims *oidc.Claims) (*data.User, error) {
+	name := claims.Name
+	if name == "" {
+		name = claims.Email
+	}
+
+	user := &data.User{
+		Name:      name,
+		Email:     claims.Email,
+		Activated: true,
+	}
+
+	err := setUnusablePassword(user)
+	if err != nil {
+		return nil, err
+	}
+
+	err = app.models.Users.Insert(user)
+	if err != nil {
+		return nil, err
+	}
+
+	err = app.models.Permissions.AddForUser(user.ID, "movies:read")
+	if err != nil {
+		return nil, err
+	}
+
+	err = app.models.Organizations.Insert(&data.Organization{Name: user.Name}, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func setUnusablePassword(user *data.User) error {
+	b := make([]byte, 32)
+
+	_, err := rand.Read(b)
+	if err != nil {
+		return err
+	}
+
+	return user.Password.Set(hex.EncodeToString(b))
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/pharsha1995/greenlight/internal/data"
+	"github.com/pharsha1995/greenlight/internal/data/validator"
+)
+
+func (app *application) listOrganizationsHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.contextGetUser(r)
+
+	orgs, err := app.models.Organizations.GetAllForUser(user.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, &envelope{"organizations": orgs}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) createOrganizationHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Name string `json:"name"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	org := &data.Organization{Name: input.Name}
+
+	v := validator.New()
+
+	if data.ValidateOrganization(v, org); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	user := app.contextGetUser(r)
+
+	err = app.models.Organizations.Insert(org, user.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusCreated, &envelope{"organization": org}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) listOrganizationMembersHandler(w http.ResponseWriter, r *http.Request) {
+	caller, ok := app.readOrganizationParam(w, r, data.OrganizationRoleViewer)
+	if !ok {
+		return
+	}
+
+	members, err := app.models.Organizations.GetAllMembers(caller.OrganizationID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, &envelope{"members": members}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) addOrganizationMemberHandler(w http.ResponseWriter, r *http.Request) {
+	caller, ok := app.readOrganizationParam(w, r, data.OrganizationRoleAdmin)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Email string `json:"email"`
+		Role  string `json:"role"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+
+	data.ValidateEmail(v, input.Email)
+	data.ValidateOrganizationRole(v, input.Role)
+
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	if input.Role == data.OrganizationRoleOwner && !caller.HasRole(data.OrganizationRoleOwner) {
+		app.notPermittedResponse(w, r)
+		return
+	}
+
+	user, err := app.models.Users.GetByEmail(input.Email)
+	if err != nil {
+		if errors.Is(err, data.ErrNoRecord) {
+			v.AddError("email", "no matching user account found")
+			app.failedValidationResponse(w, r, v.Errors)
+		} else {
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	member := &data.Member{
+		OrganizationID: caller.OrganizationID,
+		UserID:         user.ID,
+		Name:           user.Name,
+		Email:          user.Email,
+		Role:           input.Role,
+	}
+
+	err = app.models.Organizations.AddMember(member)
+	if err != nil {
+		if errors.Is(err, data.ErrDuplicateMember) {
+			v.AddError("email", "this user is already a member of the organization")
+			app.failedValidationResponse(w, r, v.Errors)
+		} else {
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusCreated, &envelope{"member": member}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) updateOrganizationMemberHandler(w http.ResponseWriter, r *http.Request) {
+	caller, ok := app.readOrganizationParam(w, r, data.OrganizationRoleAdmin)
+	if !ok {
+		return
+	}
+
+	member, ok := app.readMemberParam(w, r, caller)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Role string `json:"role"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+
+	if data.ValidateOrganizationRole(v, input.Role); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	if input.Role == data.OrganizationRoleOwner && !caller.HasRole(data.OrganizationRoleOwner) {
+		app.notPermittedResponse(w, r)
+		return
+	}
+
+	member.Role = input.Role
+
+	err = app.models.Organizations.UpdateMemberRole(member)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrNoRecord):
+			app.notFoundResponse(w, r)
+		case errors.Is(err, data.ErrLastOwner):
+			app.conflictResponse(w, r, "the organization must keep at least one owner")
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, &envelope{"member": member}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) removeOrganizationMemberHandler(w http.ResponseWriter, r *http.Request) {
+	caller, ok := app.readOrganizationParam(w, r, data.OrganizationRoleViewer)
+	if !ok {
+		return
+	}
+
+	member, ok := app.readMemberParam(w, r, caller)
+	if !ok {
+		return
+	}
+
+	if member.UserID != caller.UserID && !caller.HasRole(data.OrganizationRoleAdmin) {
+		app.notPermittedResponse(w, r)
+		return
+	}
+
+	err := app.models.Organizations.RemoveMember(member.OrganizationID, member.UserID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrNoRecord):
+			app.notFoundResponse(w, r)
+		case errors.Is(err, data.ErrLastOwner):
+			app.conflictResponse(w, r, "the organization must keep at least one owner")
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, &envelope{"message": "member successfully removed"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) readOrganizationParam(w http.ResponseWriter, r *http.Request, role string) (*data.Member, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return nil, false
+	}
+
+	user := app.contextGetUser(r)
+
+	member, err := app.models.Organizations.GetMember(id, user.ID)
+	if err != nil {
+		if errors.Is(err, data.ErrNoRecord) {
+			app.notFoundResponse(w, r)
+		} else {
+			app.serverErrorResponse(w, r, err)
+		}
+		return nil, false
+	}
+
+	if !member.HasRole(role) {
+		app.notPermittedResponse(w, r)
+		return nil, false
+	}
+
+	return member, true
+}
+
+func (app *application) readMemberParam(w http.ResponseWriter, r *http.Request, caller *data.Member) (*data.Member, bool) {
+	userID, err := strconv.ParseInt(r.PathValue("user_id"), 10, 64)
+	if err != nil || userID < 1 {
+		app.notFoundResponse(w, r)
+		return nil, false
+	}
+
+	member, err := app.models.Organizations.GetMember(caller.OrganizationID, userID)
+	if err != nil {
+		if errors.Is(err, data.ErrNoRecord) {
+			app.notFoundResponse(w, r)
+		} else {
+			app.serverErrorResponse(w, r, err)
+		}
+		return nil, false
+	}
+
+	if member.Role == data.OrganizationRoleOwner && member.UserID != caller.UserID && !caller.HasRole(data.OrganizationRoleOwner) {
+		app.notPermittedResponse(w, r)
+		return nil, false
+	}
+
+	return member, true
+}
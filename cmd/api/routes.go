@@ -1,22 +1,101 @@
 package main
 
-import "net/http"
+import (
+	"expvar"
+	"net/http"
+
+	"github.com/pharsha1995/greenlight/internal/data"
+)
+
+var moviesWritePermissions = []string{"movies:write", "movies:write:own"}
 
 func (app *application) routes() http.Handler {
 	mux := http.NewServeMux()
 
 	mux.HandleFunc("GET /v1/healthcheck", app.healthcheckHandler)
+	mux.HandleFunc("GET /debug/vars", app.requirePermission("metrics:read", expvar.Handler().ServeHTTP))
 
-	mux.HandleFunc("GET /v1/movies", app.requirePermission("movies:read", app.listMoviesHandler))
-	mux.HandleFunc("GET /v1/movies/{id}", app.requirePermission("movies:read", app.showMovieHandler))
-	mux.HandleFunc("POST /v1/movies", app.requirePermission("movies:write", app.createMovieHandler))
-	mux.HandleFunc("PATCH /v1/movies/{id}", app.requirePermission("movies:write", app.updateMovieHandler))
-	mux.HandleFunc("DELETE /v1/movies/{id}", app.requirePermission("movies:write", app.deleteMovieHandler))
+	mux.HandleFunc("GET /v1/movies", app.requirePermission("movies:read", app.requireOrganization(data.OrganizationRoleViewer, app.listMoviesHandler)))
+	mux.HandleFunc("GET /v1/movies/{id}", app.requirePermission("movies:read", app.requireOrganization(data.OrganizationRoleViewer, app.showMovieHandler)))
+	mux.HandleFunc("POST /v1/movies", app.requireAnyPermission(moviesWritePermissions, app.requireOrganization(data.OrganizationRoleEditor, app.createMovieHandler)))
+	mux.HandleFunc("PATCH /v1/movies/{id}", app.requireAnyPermission(moviesWritePermissions, app.requireOrganization(data.OrganizationRoleEditor, app.updateMovieHandler)))
+	mux.HandleFunc("DELETE /v1/movies/{id}", app.requireAnyPermission(moviesWritePermissions, app.requireOrganization(data.OrganizationRoleEditor, app.deleteMovieHandler)))
 
 	mux.HandleFunc("POST /v1/users", app.registerUserHandler)
+	mux.HandleFunc("PUT /v1/invitations/accepted", app.acceptInvitationHandler)
 	mux.HandleFunc("PUT /v1/users/activated", app.activateUserHandler)
+	mux.HandleFunc("PUT /v1/users/password", app.updateUserPasswordHandler)
+	mux.HandleFunc("PUT /v1/users/email", app.confirmEmailChangeHandler)
+	mux.HandleFunc("GET /v1/users/me", app.requireAuthenticatedUser(app.showCurrentUserHandler))
+	mux.HandleFunc("DELETE /v1/users/me", app.requireLoginSession(app.deleteCurrentUserHandler))
+	mux.HandleFunc("PATCH /v1/users/me", app.requireLoginSession(app.updateCurrentUserHandler))
+	mux.HandleFunc("POST /v1/users/me/email", app.requireLoginSession(app.requestEmailChangeHandler))
+	mux.HandleFunc("POST /v1/users/me/totp", app.requireLoginSession(app.enrollTOTPHandler))
+	mux.HandleFunc("PUT /v1/users/me/totp", app.requireLoginSession(app.confirmTOTPHandler))
+	mux.HandleFunc("DELETE /v1/users/me/totp", app.requireLoginSession(app.deleteTOTPHandler))
 
+	mux.HandleFunc("POST /v1/tokens/activation", app.createActivationTokenHandler)
 	mux.HandleFunc("POST /v1/tokens/authentication", app.createAuthenticationTokenHandler)
+	mux.HandleFunc("POST /v1/tokens/authentication/totp", app.createTOTPAuthenticationTokenHandler)
+	mux.HandleFunc("GET /v1/tokens/authentication", app.requireLoginSession(app.listAuthenticationTokensHandler))
+	mux.HandleFunc("DELETE /v1/tokens/authentication", app.requireAuthenticatedUser(app.deleteAuthenticationTokenHandler))
+	mux.HandleFunc("DELETE /v1/tokens/authentication/all", app.requireLoginSession(app.deleteAllAuthenticationTokensHandler))
+	mux.HandleFunc("POST /v1/tokens/refresh", app.refreshAuthenticationTokenHandler)
+	mux.HandleFunc("POST /v1/tokens/password-reset", app.createPasswordResetTokenHandler)
+	mux.HandleFunc("POST /v1/tokens/magic-link", app.createMagicLinkTokenHandler)
+	mux.HandleFunc("PUT /v1/tokens/magic-link", app.exchangeMagicLinkTokenHandler)
+	mux.HandleFunc("GET /v1/oidc/authorize", app.createOIDCAuthorizationHandler)
+	mux.HandleFunc("POST /v1/tokens/oidc", app.createOIDCAuthenticationTokenHandler)
+
+	mux.HandleFunc("POST /v1/api-keys", app.requireActivatedUser(app.requireLoginSession(app.createAPIKeyHandler)))
+	mux.HandleFunc("GET /v1/api-keys", app.requireActivatedUser(app.requireLoginSession(app.listAPIKeysHandler)))
+	mux.HandleFunc("DELETE /v1/api-keys/{id}", app.requireActivatedUser(app.requireLoginSession(app.deleteAPIKeyHandler)))
+
+	mux.HandleFunc("POST /v1/signing-keys", app.requireActivatedUser(app.requireLoginSession(app.createSigningKeyHandler)))
+	mux.HandleFunc("GET /v1/signing-keys", app.requireActivatedUser(app.requireLoginSession(app.listSigningKeysHandler)))
+	mux.HandleFunc("DELETE /v1/signing-keys/{id}", app.requireActivatedUser(app.requireLoginSession(app.deleteSigningKeyHandler)))
+
+	mux.HandleFunc("GET /v1/oauth/authorize", app.requireActivatedUser(app.requireLoginSession(app.showOAuthAuthorizationHandler)))
+	mux.HandleFunc("POST /v1/oauth/authorize", app.requireActivatedUser(app.requireLoginSession(app.approveOAuthAuthorizationHandler)))
+	mux.HandleFunc("POST /v1/oauth/token", app.createOAuthTokenHandler)
+	mux.HandleFunc("POST /v1/oauth/introspect", app.introspectOAuthTokenHandler)
+	mux.HandleFunc("GET /v1/oauth/clients", app.requirePermission("oauth:admin", app.listOAuthClientsHandler))
+	mux.HandleFunc("POST /v1/oauth/clients", app.requirePermission("oauth:admin", app.createOAuthClientHandler))
+	mux.HandleFunc("DELETE /v1/oauth/clients/{client_id}", app.requirePermission("oauth:admin", app.deleteOAuthClientHandler))
+
+	mux.HandleFunc("GET /v1/organizations", app.requireActivatedUser(app.listOrganizationsHandler))
+	mux.HandleFunc("POST /v1/organizations", app.requireActivatedUser(app.requireLoginSession(app.createOrganizationHandler)))
+	mux.HandleFunc("GET /v1/organizations/{id}/members", app.requireActivatedUser(app.requireLoginSession(app.listOrganizationMembersHandler)))
+	mux.HandleFunc("POST /v1/organizations/{id}/members", app.requireActivatedUser(app.requireLoginSession(app.addOrganizationMemberHandler)))
+	mux.HandleFunc("PATCH /v1/organizations/{id}/members/{user_id}", app.requireActivatedUser(app.requireLoginSession(app.updateOrganizationMemberHandler)))
+	mux.HandleFunc("DELETE /v1/organizations/{id}/members/{user_id}", app.requireActivatedUser(app.requireLoginSession(app.removeOrganizationMemberHandler)))
+
+	mux.HandleFunc("GET /v1/admin/users", app.requirePermission("users:admin", app.listUsersHandler))
+	mux.HandleFunc("GET /v1/admin/users/{id}", app.requirePermission("users:admin", app.showUserHandler))
+	mux.HandleFunc("PATCH /v1/admin/users/{id}", app.requirePermission("users:admin", app.updateUserHandler))
+	mux.HandleFunc("POST /v1/admin/users/{id}/password-reset", app.requirePermission("users:admin", app.forcePasswordResetHandler))
+	mux.HandleFunc("DELETE /v1/admin/users/{id}", app.requirePermission("users:admin", app.deleteUserHandler))
+
+	mux.HandleFunc("GET /v1/admin/invitations", app.requirePermission("users:admin", app.listInvitationsHandler))
+	mux.HandleFunc("POST /v1/admin/invitations", app.requirePermission("users:admin", app.createInvitationHandler))
+	mux.HandleFunc("DELETE /v1/admin/invitations/{id}", app.requirePermission("users:admin", app.deleteInvitationHandler))
+
+	mux.HandleFunc("GET /v1/admin/client-certificates", app.requirePermission("users:admin", app.listClientCertificatesHandler))
+	mux.HandleFunc("POST /v1/admin/client-certificates", app.requirePermission("users:admin", app.createClientCertificateHandler))
+	mux.HandleFunc("DELETE /v1/admin/client-certificates/{id}", app.requirePermission("users:admin", app.deleteClientCertificateHandler))
+
+	mux.HandleFunc("GET /v1/admin/permissions", app.requirePermission("permissions:admin", app.listPermissionsHandler))
+	mux.HandleFunc("POST /v1/admin/permissions", app.requirePermission("permissions:admin", app.createPermissionHandler))
+	mux.HandleFunc("GET /v1/admin/permissions/audit", app.requirePermission("permissions:admin", app.listPermissionAuditHandler))
+	mux.HandleFunc("POST /v1/admin/users/{id}/permissions", app.requirePermission("permissions:admin", app.grantUserPermissionsHandler))
+	mux.HandleFunc("DELETE /v1/admin/users/{id}/permissions/{code}", app.requirePermission("permissions:admin", app.revokeUserPermissionHandler))
+
+	mux.HandleFunc("GET /v1/admin/roles", app.requirePermission("permissions:admin", app.listRolesHandler))
+	mux.HandleFunc("POST /v1/admin/roles", app.requirePermission("permissions:admin", app.createRoleHandler))
+	mux.HandleFunc("POST /v1/admin/roles/{name}/permissions", app.requirePermission("permissions:admin", app.addRolePermissionsHandler))
+	mux.HandleFunc("DELETE /v1/admin/roles/{name}/permissions/{code}", app.requirePermission("permissions:admin", app.removeRolePermissionHandler))
+	mux.HandleFunc("POST /v1/admin/users/{id}/roles", app.requirePermission("permissions:admin", app.assignUserRolesHandler))
+	mux.HandleFunc("DELETE /v1/admin/users/{id}/roles/{name}", app.requirePermission("permissions:admin", app.unassignUserRoleHandler))
 
 	return app.recoverPanic(app.enableCORS(app.rateLimit(app.authenticate(mux))))
 }
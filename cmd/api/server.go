@@ -22,6 +22,17 @@ func (app *application) serve() error {
 		ErrorLog:     slog.NewLogLogger(app.logger.Handler(), slog.LevelError),
 	}
 
+	if app.config.tls.certFile != "" || app.config.tls.keyFile != "" {
+		tlsConfig, reloader, err := app.newTLSConfig()
+		if err != nil {
+			return err
+		}
+
+		server.TLSConfig = tlsConfig
+
+		go app.watchCertificate(reloader, app.config.tls.reloadInterval)
+	}
+
 	shutdownError := make(chan error)
 
 	go func() {
@@ -47,9 +58,15 @@ func (app *application) serve() error {
 		shutdownError <- nil
 	}()
 
-	app.logger.Info("starting server", "addr", server.Addr, "env", app.config.env)
+	app.logger.Info("starting server", "addr", server.Addr, "env", app.config.env, "tls", server.TLSConfig != nil)
 
-	err := server.ListenAndServe()
+	var err error
+
+	if server.TLSConfig != nil {
+		err = server.ListenAndServeTLS("", "")
+	} else {
+		err = server.ListenAndServe()
+	}
 	if !errors.Is(err, http.ErrServerClosed) {
 		return err
 	}
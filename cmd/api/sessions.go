@@ -0,0 +1,121 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/pharsha1995/greenlight/internal/data"
+	"github.com/pharsha1995/greenlight/internal/data/validator"
+)
+
+const (
+	sessionCookieName = "greenlight_session"
+	csrfCookieName    = "greenlight_csrf"
+	csrfHeaderName    = "X-CSRF-Token"
+)
+
+func wantsCookieSession(r *http.Request) bool {
+	return r.URL.Query().Get("session") == "cookie"
+}
+
+func csrfToken(sessionToken string) string {
+	mac := hmac.New(sha256.New, []byte(sessionToken))
+	mac.Write([]byte("greenlight-csrf"))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	default:
+		return false
+	}
+}
+
+func (app *application) newCookieSessionResponse(w http.ResponseWriter, r *http.Request, user *data.User) {
+	family, err := data.NewTokenFamily()
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	token, err := app.models.Tokens.NewSession(user.ID, app.config.session.ttl, data.ScopeSession, family, r.UserAgent())
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	csrf := csrfToken(token.Plaintext)
+
+	http.SetCookie(w, app.sessionCookie(sessionCookieName, token.Plaintext, token.Expiry, true))
+	http.SetCookie(w, app.sessionCookie(csrfCookieName, csrf, token.Expiry, false))
+
+	token.Plaintext = ""
+
+	err = app.writeJSON(w, http.StatusCreated, &envelope{"session": token, "csrf_token": csrf}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) sessionCookie(name, value string, expiry time.Time, httpOnly bool) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		Expires:  expiry,
+		HttpOnly: httpOnly,
+		Secure:   app.config.session.secureCookie,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+func (app *application) clearSessionCookies(w http.ResponseWriter) {
+	for _, name := range []string{sessionCookieName, csrfCookieName} {
+		cookie := app.sessionCookie(name, "", time.Unix(0, 0), name == sessionCookieName)
+		cookie.MaxAge = -1
+		http.SetCookie(w, cookie)
+	}
+}
+
+func (app *application) authenticateSession(w http.ResponseWriter, r *http.Request, next http.Handler, token string) {
+	v := validator.New()
+
+	if data.ValidateTokenPlaintext(v, token); !v.Valid() {
+		app.clearSessionCookies(w)
+		app.invalidAuthenticationTokenResponse(w, r)
+		return
+	}
+
+	if !isSafeMethod(r.Method) && !hmac.Equal([]byte(r.Header.Get(csrfHeaderName)), []byte(csrfToken(token))) {
+		app.invalidCSRFTokenResponse(w, r)
+		return
+	}
+
+	user, err := app.models.Users.GetForToken(data.ScopeSession, token)
+	if err != nil {
+		if errors.Is(err, data.ErrNoRecord) {
+			app.clearSessionCookies(w)
+			app.invalidAuthenticationTokenResponse(w, r)
+		} else {
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.models.Tokens.Touch(data.ScopeSession, token)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	r = app.contextSetUser(r, user)
+	r = app.contextSetToken(r, &data.Token{Plaintext: token, UserID: user.ID, Scope: data.ScopeSession})
+
+	next.ServeHTTP(w, r)
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pharsha1995/greenlight/internal/data"
+	"github.com/pharsha1995/greenlight/internal/data/validator"
+)
+
+const (
+	signatureScheme          = "HMAC-SHA256"
+	signatureTimestampHeader = "X-Greenlight-Timestamp"
+)
+
+func signatureMessage(r *http.Request, timestamp string, body []byte) string {
+	bodyHash := sha256.Sum256(body)
+	return strings.Join([]string{r.Method, r.URL.RequestURI(), timestamp, hex.EncodeToString(bodyHash[:])}, "\n")
+}
+
+func signingKeyAdditionalData(keyID string) []byte {
+	return []byte("signing-key:" + keyID)
+}
+
+func (app *application) cleanupSigningKeyUses() {
+	for {
+		time.Sleep(time.Minute)
+
+		err := app.models.SigningKeys.DeleteExpiredUses()
+		if err != nil {
+			app.logger.Error(err.Error())
+		}
+	}
+}
+
+func (app *application) createSigningKeyHandler(w http.ResponseWriter, r *http.Request) {
+	if app.encrypter == nil {
+		app.encryptionNotConfiguredResponse(w, r)
+		return
+	}
+
+	var input struct {
+		Name           string   `json:"name"`
+		Permissions    []string `json:"permissions"`
+		OrganizationID *int64   `json:"organization_id"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	user := app.contextGetUser(r)
+
+	key := &data.SigningKey{
+		UserID:         user.ID,
+		Name:           input.Name,
+		Permissions:    input.Permissions,
+		OrganizationID: input.OrganizationID,
+	}
+
+	v := validator.New()
+
+	if data.ValidateSigningKey(v, key); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	for _, code := range key.Permissions {
+		if data.IsDenyPermission(code) {
+			continue
+		}
+
+		permitted, err := app.hasPermission(r, code)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+
+		if !permitted {
+			v.AddError("permissions", "must be a subset of your own permissions")
+			app.failedValidationResponse(w, r, v.Errors)
+			return
+		}
+	}
+
+	if key.OrganizationID != nil {
+		_, err = app.models.Organizations.GetMember(*key.OrganizationID, user.ID)
+		if err != nil {
+			if errors.Is(err, data.ErrNoRecord) {
+				v.AddError("organization_id", "must be an organization you are a member of")
+				app.failedValidationResponse(w, r, v.Errors)
+			} else {
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+	}
+
+	err = data.GenerateSigningKey(key)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	key.EncryptedSecret, err = app.encrypter.Encrypt([]byte(key.Secret), signingKeyAdditionalData(key.KeyID))
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.models.SigningKeys.Insert(key)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusCreated, &envelope{"signing_key": key}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) listSigningKeysHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.contextGetUser(r)
+
+	keys, err := app.models.SigningKeys.GetAllForUser(user.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, &envelope{"signing_keys": keys}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) deleteSigningKeyHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	user := app.contextGetUser(r)
+
+	err = app.models.SigningKeys.Delete(id, user.ID)
+	if err != nil {
+		if errors.Is(err, data.ErrNoRecord) {
+			app.notFoundResponse(w, r)
+		} else {
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, &envelope{"message": "signing key successfully revoked"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) authenticateSignature(w http.ResponseWriter, r *http.Request, next http.Handler, credentials string) {
+	keyID, signature, ok := strings.Cut(credentials, ":")
+	if !ok {
+		app.invalidSignatureResponse(w, r)
+		return
+	}
+
+	v := validator.New()
+
+	if data.ValidateSigningKeyID(v, keyID); !v.Valid() {
+		app.invalidSignatureResponse(w, r)
+		return
+	}
+
+	if app.encrypter == nil {
+		app.encryptionNotConfiguredResponse(w, r)
+		return
+	}
+
+	timestamp := r.Header.Get(signatureTimestampHeader)
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		app.invalidSignatureResponse(w, r)
+		return
+	}
+
+	skew := time.Since(time.Unix(unix, 0))
+	if skew > app.config.signing.maxSkew || skew < -app.config.signing.maxSkew {
+		app.invalidSignatureResponse(w, r)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, 1_048_576))
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	key, user, err := app.models.SigningKeys.GetForKeyID(keyID)
+	if err != nil {
+		if errors.Is(err, data.ErrNoRecord) {
+			app.invalidSignatureResponse(w, r)
+		} else {
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	secret, err := app.encrypter.Decrypt(key.EncryptedSecret, signingKeyAdditionalData(key.KeyID))
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	if !data.VerifySignature(secret, signatureMessage(r, timestamp, body), signature) {
+		app.invalidSignatureResponse(w, r)
+		return
+	}
+
+	err = app.models.SigningKeys.RecordUse(key.KeyID, strings.ToLower(signature), time.Unix(unix, 0).Add(app.config.signing.maxSkew))
+	if err != nil {
+		if errors.Is(err, data.ErrReplayedSignature) {
+			app.invalidSignatureResponse(w, r)
+		} else {
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	r = app.contextSetUser(r, user)
+	r = app.contextSetToken(r, &data.Token{UserID: user.ID, Scope: data.ScopeSigningKey, OrganizationID: key.OrganizationID})
+	r = app.contextSetPermissionScope(r, key.Permissions)
+
+	next.ServeHTTP(w, r)
+}
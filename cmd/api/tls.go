@@ -0,0 +1,150 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+type certificateReloader struct {
+	certFile string
+	keyFile  string
+	mu       sync.RWMutex
+	cert     *tls.Certificate
+	modTime  time.Time
+}
+
+func newCertificateReloader(certFile, keyFile string) (*certificateReloader, error) {
+	c := &certificateReloader{certFile: certFile, keyFile: keyFile}
+
+	modTime, err := c.lastModified()
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.load(modTime)
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (c *certificateReloader) lastModified() (time.Time, error) {
+	var latest time.Time
+
+	for _, file := range []string{c.certFile, c.keyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+
+	return latest, nil
+}
+
+func (c *certificateReloader) load(modTime time.Time) error {
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.cert = &cert
+	c.modTime = modTime
+	c.mu.Unlock()
+
+	return nil
+}
+
+func (c *certificateReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.cert, nil
+}
+
+func (app *application) watchCertificate(c *certificateReloader, interval time.Duration) {
+	for range time.Tick(interval) {
+		modTime, err := c.lastModified()
+		if err != nil {
+			app.logger.Error("checking TLS certificate", "error", err.Error())
+			continue
+		}
+
+		c.mu.RLock()
+		changed := modTime.After(c.modTime)
+		c.mu.RUnlock()
+
+		if !changed {
+			continue
+		}
+
+		err = c.load(modTime)
+		if err != nil {
+			app.logger.Error("reloading TLS certificate", "error", err.Error())
+			continue
+		}
+
+		app.logger.Info("reloaded TLS certificate", "cert", c.certFile)
+	}
+}
+
+func (app *application) newTLSConfig() (*tls.Config, *certificateReloader, error) {
+	cfg := app.config.tls
+
+	if cfg.certFile == "" || cfg.keyFile == "" {
+		return nil, nil, errors.New("both -tls-cert and -tls-key must be provided to enable TLS")
+	}
+
+	tlsConfig := &tls.Config{}
+
+	switch cfg.minVersion {
+	case "1.2":
+		tlsConfig.MinVersion = tls.VersionTLS12
+	case "1.3":
+		tlsConfig.MinVersion = tls.VersionTLS13
+	default:
+		return nil, nil, fmt.Errorf("unsupported -tls-min-version %q", cfg.minVersion)
+	}
+
+	if cfg.clientCA != "" {
+		pem, err := os.ReadFile(cfg.clientCA)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, nil, fmt.Errorf("no certificates found in %s", cfg.clientCA)
+		}
+
+		tlsConfig.ClientCAs = pool
+
+		switch cfg.clientAuth {
+		case "optional":
+			tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+		case "require":
+			tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+		default:
+			return nil, nil, fmt.Errorf("unsupported -tls-client-auth %q", cfg.clientAuth)
+		}
+	}
+
+	reloader, err := newCertificateReloader(cfg.certFile, cfg.keyFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tlsConfig.GetCertificate = reloader.GetCertificate
+
+	return tlsConfig, reloader, nil
+}
@@ -1,12 +1,15 @@
 package main
 
 import (
+	"encoding/base64"
 	"errors"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/pharsha1995/greenlight/internal/data"
 	"github.com/pharsha1995/greenlight/internal/data/validator"
+	"github.com/pharsha1995/greenlight/internal/jwt"
 )
 
 func (app *application) createAuthenticationTokenHandler(w http.ResponseWriter, r *http.Request) {
@@ -31,6 +34,23 @@ func (app *application) createAuthenticationTokenHandler(w http.ResponseWriter,
 		return
 	}
 
+	accountKey, ipKey, err := loginAttemptKeys(r, input.Email)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	allowed, locked, err := app.reserveLoginAttempt(accountKey, ipKey)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	if !allowed {
+		app.invalidCredentialsResponse(w, r)
+		return
+	}
+
 	user, err := app.models.Users.GetByEmail(input.Email)
 	if err != nil {
 		if errors.Is(err, data.ErrNoRecord) {
@@ -41,24 +61,490 @@ func (app *application) createAuthenticationTokenHandler(w http.ResponseWriter,
 		return
 	}
 
-	match, err := user.Password.Matches(input.Password)
+	match, rehash, err := user.Password.Matches(input.Password)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 		return
 	}
 
 	if !match {
+		if locked {
+			app.notifyAccountLocked(user)
+		}
+
+		app.invalidCredentialsResponse(w, r)
+		return
+	}
+
+	if rehash {
+		app.upgradePasswordHash(user, input.Password)
+	}
+
+	enabled, err := app.models.TOTP.Enabled(user.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.releaseLoginAttempt(accountKey, ipKey, !enabled)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	if enabled {
+		app.totpChallengeResponse(w, r, user)
+		return
+	}
+
+	app.newSessionResponse(w, r, user)
+}
+
+func (app *application) createTOTPAuthenticationTokenHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		TokenPlaintext string `json:"token"`
+		Code           string `json:"code"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+
+	data.ValidateTokenPlaintext(v, input.TokenPlaintext)
+	v.Check(input.Code != "", "code", "must be provided")
+
+	if !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	if app.encrypter == nil {
+		app.encryptionNotConfiguredResponse(w, r)
+		return
+	}
+
+	user, err := app.models.Users.GetForToken(data.ScopeTOTPChallenge, input.TokenPlaintext)
+	if err != nil {
+		if errors.Is(err, data.ErrNoRecord) {
+			v.AddError("token", "invalid or expired two-factor challenge token")
+			app.failedValidationResponse(w, r, v.Errors)
+		} else {
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	accountKey, ipKey, err := loginAttemptKeys(r, user.Email)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	allowed, locked, err := app.reserveLoginAttempt(accountKey, ipKey)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	if !allowed {
+		app.invalidCredentialsResponse(w, r)
+		return
+	}
+
+	err = app.models.Tokens.DeleteAllForUser(data.ScopeTOTPChallenge, user.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	valid, err := app.verifyTOTP(user.ID, input.Code)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	if !valid {
+		if locked {
+			app.notifyAccountLocked(user)
+		}
+
 		app.invalidCredentialsResponse(w, r)
 		return
 	}
 
-	token, err := app.models.Tokens.New(user.ID, 24*time.Hour, data.ScopeAuthentication)
+	err = app.releaseLoginAttempt(accountKey, ipKey, true)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	app.newSessionResponse(w, r, user)
+}
+
+func (app *application) upgradePasswordHash(user *data.User, plaintextPassword string) {
+	err := user.Password.Set(plaintextPassword)
+	if err == nil {
+		err = app.models.Users.Update(user)
+	}
+	if err != nil {
+		app.logger.Error("unable to upgrade password hash", "user_id", user.ID, "error", err.Error())
+	}
+}
+
+func (app *application) totpChallengeResponse(w http.ResponseWriter, r *http.Request, user *data.User) {
+	token, err := app.models.Tokens.New(user.ID, 5*time.Minute, data.ScopeTOTPChallenge)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusAccepted, &envelope{"totp_challenge": token}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) newSessionResponse(w http.ResponseWriter, r *http.Request, user *data.User) {
+	if wantsCookieSession(r) {
+		app.newCookieSessionResponse(w, r, user)
+		return
+	}
+
+	family, err := data.NewTokenFamily()
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	env, err := app.newSession(user.ID, family, r.UserAgent())
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusCreated, &env, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) refreshAuthenticationTokenHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		TokenPlaintext string `json:"token"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+
+	if data.ValidateTokenPlaintext(v, input.TokenPlaintext); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	token, err := app.models.Tokens.UseRefreshToken(input.TokenPlaintext)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrTokenReused):
+			app.logger.Warn("refresh token reused, revoking token family", "user_id", token.UserID)
+
+			err = app.models.Tokens.DeleteFamily(token.Family)
+			if err != nil {
+				app.serverErrorResponse(w, r, err)
+				return
+			}
+
+			v.AddError("token", "invalid or expired refresh token")
+			app.failedValidationResponse(w, r, v.Errors)
+
+		case errors.Is(err, data.ErrNoRecord):
+			v.AddError("token", "invalid or expired refresh token")
+			app.failedValidationResponse(w, r, v.Errors)
+
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	env, err := app.newSession(token.UserID, token.Family, r.UserAgent())
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusCreated, &env, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) newSession(userID int64, family []byte, userAgent string) (envelope, error) {
+	var (
+		authenticationToken *data.Token
+		err                 error
+	)
+
+	if app.jwt != nil {
+		authenticationToken, err = app.newJWTAuthenticationToken(userID, family)
+	} else {
+		authenticationToken, err = app.models.Tokens.NewSession(userID, app.config.tokens.authenticationTTL, data.ScopeAuthentication, family, userAgent)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := app.models.Tokens.NewSession(userID, app.config.tokens.refreshTTL, data.ScopeRefresh, family, userAgent)
+	if err != nil {
+		return nil, err
+	}
+
+	return envelope{"authentication_token": authenticationToken, "refresh_token": refreshToken}, nil
+}
+
+func (app *application) newJWTAuthenticationToken(userID int64, family []byte) (*data.Token, error) {
+	user, err := app.models.Users.Get(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	permissions, err := app.models.Permissions.GetAllForUser(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := jwt.Claims{
+		Subject:     strconv.FormatInt(user.ID, 10),
+		Session:     base64.RawURLEncoding.EncodeToString(family),
+		Activated:   user.Activated,
+		Permissions: permissions,
+	}
+
+	plaintext, expiry, err := app.jwt.Sign(claims, app.config.tokens.authenticationTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	token := &data.Token{
+		Plaintext: plaintext,
+		UserID:    user.ID,
+		CreatedAt: time.Now(),
+		Expiry:    expiry,
+		Scope:     data.ScopeAuthentication,
+		Family:    family,
+	}
+
+	return token, nil
+}
+
+func (app *application) listAuthenticationTokensHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.contextGetUser(r)
+
+	scope := data.ScopeAuthentication
+	if app.jwt != nil {
+		scope = data.ScopeRefresh
+	}
+
+	tokens, err := app.models.Tokens.GetAllForUser(scope, user.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	sessions, err := app.models.Tokens.GetAllForUser(data.ScopeSession, user.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	tokens = append(tokens, sessions...)
+
+	err = app.writeJSON(w, http.StatusOK, &envelope{"authentication_tokens": tokens}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) deleteAuthenticationTokenHandler(w http.ResponseWriter, r *http.Request) {
+	token := app.contextGetToken(r)
+
+	var err error
+
+	switch {
+	case token.Scope == data.ScopeAPIKey:
+		app.badRequestResponse(w, r, errors.New("API keys must be revoked through the /v1/api-keys endpoints"))
+		return
+	case token.Scope == data.ScopeSigningKey:
+		app.badRequestResponse(w, r, errors.New("signing keys must be revoked through the /v1/signing-keys endpoints"))
+		return
+	case token.Scope == data.ScopeCertificate:
+		app.badRequestResponse(w, r, errors.New("client certificates cannot be revoked through this endpoint"))
+		return
+	case token.Scope == data.ScopeOAuth:
+		err = app.models.OAuth.DeleteAccessToken(token.Plaintext)
+	case token.Family != nil:
+		err = app.models.Tokens.DeleteFamily(token.Family)
+	default:
+		err = app.models.Tokens.DeleteSession(token.Scope, token.Plaintext)
+	}
+	if err != nil {
+		if errors.Is(err, data.ErrNoRecord) {
+			app.invalidAuthenticationTokenResponse(w, r)
+		} else {
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if token.Scope == data.ScopeSession {
+		app.clearSessionCookies(w)
+	}
+
+	err = app.writeJSON(w, http.StatusOK, &envelope{"message": "authentication token successfully revoked"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) deleteAllAuthenticationTokensHandler(w http.ResponseWriter, r *http.Request) {
+	user := app.contextGetUser(r)
+
+	err := app.revokeSessions(user.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	if app.contextGetToken(r).Scope == data.ScopeSession {
+		app.clearSessionCookies(w)
+	}
+
+	err = app.writeJSON(w, http.StatusOK, &envelope{"message": "all authentication tokens successfully revoked"}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) createPasswordResetTokenHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Email string `json:"email"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
 		return
 	}
 
-	err = app.writeJSON(w, http.StatusCreated, &envelope{"authentication_token": token}, nil)
+	v := validator.New()
+
+	if data.ValidateEmail(v, input.Email); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	user, err := app.models.Users.GetByEmail(input.Email)
+	if err != nil && !errors.Is(err, data.ErrNoRecord) {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	if user != nil && user.Activated {
+		err = app.sendPasswordResetEmail(user)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+	}
+
+	env := envelope{"message": "if the email address is registered, you will receive an email containing password reset instructions"}
+
+	err = app.writeJSON(w, http.StatusAccepted, &env, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) sendPasswordResetEmail(user *data.User) error {
+	token, err := app.models.Tokens.New(user.ID, 45*time.Minute, data.ScopePasswordReset)
+	if err != nil {
+		return err
+	}
+
+	app.background(func() {
+		data := map[string]any{
+			"passwordResetToken": token.Plaintext,
+		}
+
+		err := app.mailer.Send(user.Email, "user_password_reset.tmpl", data)
+		if err != nil {
+			app.logger.Error(err.Error())
+		}
+	})
+
+	return nil
+}
+
+func (app *application) createActivationTokenHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Email string `json:"email"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+
+	if data.ValidateEmail(v, input.Email); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	user, err := app.models.Users.GetByEmail(input.Email)
+	if err != nil && !errors.Is(err, data.ErrNoRecord) {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	if user != nil && !user.Activated {
+		err = app.models.Tokens.DeleteAllForUser(data.ScopeActivation, user.ID)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+
+		token, err := app.models.Tokens.New(user.ID, 3*24*time.Hour, data.ScopeActivation)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+
+		app.background(func() {
+			data := map[string]any{
+				"activationToken": token.Plaintext,
+			}
+
+			err := app.mailer.Send(user.Email, "user_activation.tmpl", data)
+			if err != nil {
+				app.logger.Error(err.Error())
+			}
+		})
+	}
+
+	env := envelope{"message": "if the email address belongs to an account that is not yet activated, you will receive an email containing activation instructions"}
+
+	err = app.writeJSON(w, http.StatusAccepted, &env, nil)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 	}
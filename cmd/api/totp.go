@@ -0,0 +1,244 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pharsha1995/greenlight/internal/data"
+	"github.com/pharsha1995/greenlight/internal/data/validator"
+	"github.com/pharsha1995/greenlight/internal/totp"
+)
+
+func (app *application) enrollTOTPHandler(w http.ResponseWriter, r *http.Request) {
+	if app.encrypter == nil {
+		app.encryptionNotConfiguredResponse(w, r)
+		return
+	}
+
+	user, err := app.models.Users.Get(app.contextGetUser(r).ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	encryptedSecret, err := app.encrypter.Encrypt([]byte(secret), totpAdditionalData(user.ID))
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.models.TOTP.Enroll(user.ID, encryptedSecret)
+	if err != nil {
+		if errors.Is(err, data.ErrEditConflict) {
+			app.conflictResponse(w, r, "two-factor authentication is already enabled")
+		} else {
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	env := envelope{
+		"totp": map[string]string{
+			"secret":           secret,
+			"provisioning_uri": totp.ProvisioningURI("Greenlight", user.Email, secret),
+		},
+	}
+
+	err = app.writeJSON(w, http.StatusCreated, &env, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) confirmTOTPHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Code string `json:"code"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if app.encrypter == nil {
+		app.encryptionNotConfiguredResponse(w, r)
+		return
+	}
+
+	user := app.contextGetUser(r)
+
+	enrollment, err := app.models.TOTP.Get(user.ID)
+	if err != nil {
+		if errors.Is(err, data.ErrNoRecord) {
+			app.notFoundResponse(w, r)
+		} else {
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if enrollment.Confirmed {
+		app.conflictResponse(w, r, "two-factor authentication is already enabled")
+		return
+	}
+
+	secret, err := app.encrypter.Decrypt(enrollment.Secret, totpAdditionalData(user.ID))
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	step, valid, err := totp.Validate(string(secret), input.Code, time.Now())
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	if !valid {
+		v := validator.New()
+		v.AddError("code", "invalid two-factor authentication code")
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	codes, err := app.models.TOTP.Confirm(user.ID, step)
+	if err != nil {
+		if errors.Is(err, data.ErrEditConflict) {
+			app.editConflictResponse(w, r)
+		} else {
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, &envelope{"recovery_codes": codes}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) deleteTOTPHandler(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Password string `json:"password"`
+		Code     string `json:"code"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+
+	if data.ValidatePasswordPlaintext(v, input.Password); !v.Valid() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	user, err := app.models.Users.Get(app.contextGetUser(r).ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	match, _, err := user.Password.Matches(input.Password)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	if !match {
+		app.invalidCredentialsResponse(w, r)
+		return
+	}
+
+	enrollment, err := app.models.TOTP.Get(user.ID)
+	if err != nil {
+		if errors.Is(err, data.ErrNoRecord) {
+			app.notFoundResponse(w, r)
+		} else {
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if enrollment.Confirmed {
+		if input.Code == "" {
+			v.AddError("code", "must be provided")
+			app.failedValidationResponse(w, r, v.Errors)
+			return
+		}
+
+		if app.encrypter == nil {
+			app.encryptionNotConfiguredResponse(w, r)
+			return
+		}
+
+		valid, err := app.verifyTOTP(user.ID, input.Code)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+
+		if !valid {
+			app.invalidCredentialsResponse(w, r)
+			return
+		}
+	}
+
+	err = app.models.TOTP.Delete(user.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, &envelope{"message": "two-factor authentication successfully disabled"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) verifyTOTP(userID int64, code string) (bool, error) {
+	enrollment, err := app.models.TOTP.Get(userID)
+	if err != nil {
+		return false, err
+	}
+
+	secret, err := app.encrypter.Decrypt(enrollment.Secret, totpAdditionalData(userID))
+	if err != nil {
+		return false, err
+	}
+
+	step, valid, err := totp.Validate(string(secret), code, time.Now())
+	if err != nil {
+		return false, err
+	}
+
+	if valid {
+		err = app.models.TOTP.UseStep(userID, step)
+	} else {
+		err = app.models.TOTP.UseRecoveryCode(userID, code)
+	}
+	if err != nil {
+		if errors.Is(err, data.ErrNoRecord) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+func totpAdditionalData(userID int64) []byte {
+	return []byte("totp:" + strconv.FormatInt(userID, 10))
+}
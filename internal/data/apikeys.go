@@ -0,0 +1,218 @@
+package data
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base32"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/pharsha1995/greenlight/internal/data/validator"
+)
+
+const APIKeyPrefix = "gl_"
+
+type APIKey struct {
+	ID             int64       `json:"id"`
+	Plaintext      string      `json:"key,omitempty"`
+	Hash           []byte      `json:"-"`
+	UserID         int64       `json:"-"`
+	Name           string      `json:"name"`
+	Permissions    Permissions `json:"permissions"`
+	OrganizationID *int64      `json:"organization_id,omitempty"`
+	CreatedAt      time.Time   `json:"created_at"`
+	Expiry         *time.Time  `json:"expiry,omitempty"`
+	LastUsedAt     *time.Time  `json:"last_used_at,omitempty"`
+}
+
+func IsAPIKey(plaintext string) bool {
+	return strings.HasPrefix(plaintext, APIKeyPrefix)
+}
+
+func ValidateAPIKey(v *validator.Validator, key *APIKey) {
+	v.Check(validator.ValidString(key.Name, 1, 100), "name", "must not be empty and less than 100 bytes")
+	v.Check(len(key.Permissions) > 0, "permissions", "must contain at least one permission")
+	v.Check(validator.Unique(key.Permissions), "permissions", "must not contain duplicate and empty values")
+
+	if key.Expiry != nil {
+		v.Check(key.Expiry.After(time.Now()), "expiry", "must be in the future")
+	}
+}
+
+func ValidateAPIKeyPlaintext(v *validator.Validator, plaintext string) {
+	v.Check(IsAPIKey(plaintext), "key", "must be a valid API key")
+	v.Check(len(plaintext) == len(APIKeyPrefix)+52, "key", "must be a valid API key")
+}
+
+type APIKeyModel struct {
+	DB *sql.DB
+}
+
+func (m *APIKeyModel) Insert(key *APIKey) error {
+	randomBytes := make([]byte, 32)
+
+	_, err := rand.Read(randomBytes)
+	if err != nil {
+		return err
+	}
+
+	key.Plaintext = APIKeyPrefix + base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(randomBytes)
+	hash := sha256.Sum256([]byte(key.Plaintext))
+	key.Hash = hash[:]
+
+	stmt := `INSERT INTO api_keys (hash, user_id, name, permissions, organization_id, expiry)
+	         VALUES ($1, $2, $3, $4, $5, $6)
+					 RETURNING id, created_at`
+
+	args := []any{key.Hash, key.UserID, key.Name, pq.Array(key.Permissions), key.OrganizationID, key.Expiry}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return m.DB.QueryRowContext(ctx, stmt, args...).Scan(&key.ID, &key.CreatedAt)
+}
+
+func (m *APIKeyModel) GetAllForUser(userID int64) ([]*APIKey, error) {
+	stmt := `SELECT id, user_id, name, permissions, organization_id, created_at, expiry, last_used_at
+	         FROM api_keys
+					 WHERE user_id = $1
+					 ORDER BY id`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, stmt, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	keys := []*APIKey{}
+
+	for rows.Next() {
+		var key APIKey
+
+		err := rows.Scan(
+			&key.ID,
+			&key.UserID,
+			&key.Name,
+			pq.Array(&key.Permissions),
+			&key.OrganizationID,
+			&key.CreatedAt,
+			&key.Expiry,
+			&key.LastUsedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, &key)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+func (m *APIKeyModel) Delete(id, userID int64) error {
+	stmt := `DELETE FROM api_keys
+	         WHERE id = $1 AND user_id = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, stmt, id, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrNoRecord
+	}
+
+	return nil
+}
+
+func (m *APIKeyModel) DeleteAllForUser(userID int64) error {
+	stmt := `DELETE FROM api_keys
+	         WHERE user_id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, stmt, userID)
+	return err
+}
+
+func (m *APIKeyModel) GetForPlaintext(plaintext string) (*APIKey, *User, error) {
+	hash := sha256.Sum256([]byte(plaintext))
+
+	stmt := `SELECT api_keys.id, api_keys.name, api_keys.permissions, api_keys.organization_id, api_keys.created_at, api_keys.expiry, api_keys.last_used_at,
+	         users.id, users.created_at, users.name, users.email, users.password_hash, users.activated, users.version
+	         FROM api_keys
+					 INNER JOIN users ON users.id = api_keys.user_id
+					 WHERE api_keys.hash = $1
+					 AND (api_keys.expiry IS NULL OR api_keys.expiry > $2)`
+
+	var (
+		key  APIKey
+		user User
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	now := time.Now()
+
+	err := m.DB.QueryRowContext(ctx, stmt, hash[:], now).Scan(
+		&key.ID,
+		&key.Name,
+		pq.Array(&key.Permissions),
+		&key.OrganizationID,
+		&key.CreatedAt,
+		&key.Expiry,
+		&key.LastUsedAt,
+		&user.ID,
+		&user.CreateAt,
+		&user.Name,
+		&user.Email,
+		&user.Password.hash,
+		&user.Activated,
+		&user.Version,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, ErrNoRecord
+		}
+		return nil, nil, err
+	}
+
+	key.UserID = user.ID
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > time.Minute {
+		stmt = `UPDATE api_keys
+		        SET last_used_at = $2
+						WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < $3)`
+
+		_, err = m.DB.ExecContext(ctx, stmt, key.ID, now, now.Add(-time.Minute))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		key.LastUsedAt = &now
+	}
+
+	return &key, &user, nil
+}
@@ -0,0 +1,150 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/pharsha1995/greenlight/internal/data/validator"
+)
+
+var (
+	ErrDuplicateCertificate   = errors.New("certificates: subject already mapped")
+	certificateUniquePQErrMsg = `pq: duplicate key value violates unique constraint "client_certificates_subject_key"`
+)
+
+type ClientCertificate struct {
+	ID             int64     `json:"id"`
+	Subject        string    `json:"subject"`
+	UserID         int64     `json:"user_id"`
+	OrganizationID *int64    `json:"organization_id,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+func ValidateClientCertificate(v *validator.Validator, cert *ClientCertificate) {
+	v.Check(cert.Subject != "", "subject", "must be provided")
+	v.Check(len(cert.Subject) <= 1024, "subject", "must not be more than 1024 bytes long")
+	v.Check(cert.UserID > 0, "user_id", "must be provided")
+}
+
+type ClientCertificateModel struct {
+	DB *sql.DB
+}
+
+func (m *ClientCertificateModel) Insert(cert *ClientCertificate) error {
+	stmt := `INSERT INTO client_certificates (subject, user_id, organization_id)
+	         VALUES ($1, $2, $3)
+					 RETURNING id, created_at`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, stmt, cert.Subject, cert.UserID, cert.OrganizationID).Scan(&cert.ID, &cert.CreatedAt)
+	if err != nil {
+		if err.Error() == certificateUniquePQErrMsg {
+			return ErrDuplicateCertificate
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (m *ClientCertificateModel) GetAll() ([]*ClientCertificate, error) {
+	stmt := `SELECT id, subject, user_id, organization_id, created_at
+	         FROM client_certificates
+					 ORDER BY id`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	certs := []*ClientCertificate{}
+
+	for rows.Next() {
+		var cert ClientCertificate
+
+		err := rows.Scan(&cert.ID, &cert.Subject, &cert.UserID, &cert.OrganizationID, &cert.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		certs = append(certs, &cert)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return certs, nil
+}
+
+func (m *ClientCertificateModel) Delete(id int64) error {
+	stmt := `DELETE FROM client_certificates
+	         WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, stmt, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrNoRecord
+	}
+
+	return nil
+}
+
+func (m *ClientCertificateModel) GetForSubject(subject string) (*ClientCertificate, *User, error) {
+	stmt := `SELECT client_certificates.id, client_certificates.subject, client_certificates.user_id, client_certificates.organization_id, client_certificates.created_at,
+	         users.id, users.created_at, users.name, users.email, users.password_hash, users.activated, users.version
+	         FROM client_certificates
+					 INNER JOIN users ON users.id = client_certificates.user_id
+					 WHERE client_certificates.subject = $1`
+
+	var (
+		cert ClientCertificate
+		user User
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, stmt, subject).Scan(
+		&cert.ID,
+		&cert.Subject,
+		&cert.UserID,
+		&cert.OrganizationID,
+		&cert.CreatedAt,
+		&user.ID,
+		&user.CreateAt,
+		&user.Name,
+		&user.Email,
+		&user.Password.hash,
+		&user.Activated,
+		&user.Version,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, ErrNoRecord
+		}
+		return nil, nil, err
+	}
+
+	return &cert, &user, nil
+}
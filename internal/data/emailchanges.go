@@ -0,0 +1,57 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+type EmailChangeModel struct {
+	DB *sql.DB
+}
+
+func (m *EmailChangeModel) Upsert(userID int64, email string) error {
+	stmt := `INSERT INTO email_changes (user_id, email)
+	         VALUES ($1, $2)
+					 ON CONFLICT (user_id) DO UPDATE
+					 SET email = EXCLUDED.email, created_at = NOW()`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, stmt, userID, email)
+	return err
+}
+
+func (m *EmailChangeModel) Get(userID int64) (string, error) {
+	stmt := `SELECT email
+	         FROM email_changes
+					 WHERE user_id = $1`
+
+	var email string
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, stmt, userID).Scan(&email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", ErrNoRecord
+		}
+		return "", err
+	}
+
+	return email, nil
+}
+
+func (m *EmailChangeModel) Delete(userID int64) error {
+	stmt := `DELETE FROM email_changes
+	         WHERE user_id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, stmt, userID)
+	return err
+}
@@ -0,0 +1,77 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+var (
+	ErrDuplicateIdentity   = errors.New("identities: identity already linked")
+	identityUniquePQErrMsg = `pq: duplicate key value violates unique constraint "user_identities_issuer_subject_key"`
+)
+
+type Identity struct {
+	ID        int64     `json:"id"`
+	UserID    int64     `json:"-"`
+	Issuer    string    `json:"issuer"`
+	Subject   string    `json:"subject"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type IdentityModel struct {
+	DB *sql.DB
+}
+
+func (m *IdentityModel) Insert(identity *Identity) error {
+	stmt := `INSERT INTO user_identities (user_id, issuer, subject, email)
+	         VALUES ($1, $2, $3, $4)
+					 RETURNING id, created_at`
+
+	args := []any{identity.UserID, identity.Issuer, identity.Subject, identity.Email}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, stmt, args...).Scan(&identity.ID, &identity.CreatedAt)
+	if err != nil {
+		if err.Error() == identityUniquePQErrMsg {
+			return ErrDuplicateIdentity
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (m *IdentityModel) GetUser(issuer, subject string) (*User, error) {
+	stmt := `SELECT users.id, users.created_at, users.name, users.email, users.password_hash, users.activated, users.version
+	         FROM users
+					 INNER JOIN user_identities ON user_identities.user_id = users.id
+					 WHERE user_identities.issuer = $1 AND user_identities.subject = $2`
+
+	var user User
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, stmt, issuer, subject).Scan(
+		&user.ID,
+		&user.CreateAt,
+		&user.Name,
+		&user.Email,
+		&user.Password.hash,
+		&user.Activated,
+		&user.Version,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNoRecord
+		}
+		return nil, err
+	}
+
+	return &user, nil
+}
@@ -0,0 +1,281 @@
+package data
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/pharsha1995/greenlight/internal/data/validator"
+)
+
+type Invitation struct {
+	ID               int64       `json:"id"`
+	Plaintext        string      `json:"-"`
+	Hash             []byte      `json:"-"`
+	Email            string      `json:"email"`
+	Permissions      Permissions `json:"permissions"`
+	OrganizationID   *int64      `json:"organization_id,omitempty"`
+	OrganizationRole *string     `json:"organization_role,omitempty"`
+	InvitedBy        *int64      `json:"invited_by,omitempty"`
+	CreatedAt        time.Time   `json:"created_at"`
+	Expiry           time.Time   `json:"expiry"`
+}
+
+func ValidateInvitation(v *validator.Validator, inv *Invitation) {
+	ValidateEmail(v, inv.Email)
+
+	v.Check(validator.Unique(inv.Permissions), "permissions", "must not contain duplicate and empty values")
+
+	for _, code := range inv.Permissions {
+		ValidatePermissionCode(v, "permissions", code)
+	}
+
+	if inv.OrganizationID != nil {
+		v.Check(inv.OrganizationRole != nil, "organization_role", "must be provided together with organization_id")
+	}
+
+	if inv.OrganizationRole != nil {
+		v.Check(inv.OrganizationID != nil, "organization_id", "must be provided together with organization_role")
+		v.Check(validator.PermittedValue(*inv.OrganizationRole, OrganizationRoles...), "organization_role", "must be one of viewer, editor, admin or owner")
+	}
+}
+
+type InvitationModel struct {
+	DB *sql.DB
+}
+
+func (m *InvitationModel) Insert(inv *Invitation, ttl time.Duration) error {
+	token, err := generateToken(0, ttl, ScopeInvitation)
+	if err != nil {
+		return err
+	}
+
+	inv.Plaintext = token.Plaintext
+	inv.Hash = token.Hash
+	inv.Expiry = token.Expiry
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	var known int
+
+	err = tx.QueryRowContext(ctx, `SELECT count(*) FROM permissions WHERE code = ANY($1)`, pq.Array(inv.Permissions)).Scan(&known)
+	if err != nil {
+		return err
+	}
+
+	if known != len(inv.Permissions) {
+		return ErrUnknownPermission
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM invitations WHERE email = $1 AND accepted_at IS NULL`, inv.Email)
+	if err != nil {
+		return err
+	}
+
+	stmt := `INSERT INTO invitations (hash, email, permissions, organization_id, organization_role, invited_by, expiry)
+	         VALUES ($1, $2, $3, $4, $5, $6, $7)
+					 RETURNING id, created_at`
+
+	args := []any{
+		inv.Hash,
+		inv.Email,
+		pq.Array(inv.Permissions),
+		inv.OrganizationID,
+		inv.OrganizationRole,
+		inv.InvitedBy,
+		inv.Expiry,
+	}
+
+	err = tx.QueryRowContext(ctx, stmt, args...).Scan(&inv.ID, &inv.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (m *InvitationModel) GetAllPending() ([]*Invitation, error) {
+	stmt := `SELECT id, email, permissions, organization_id, organization_role, invited_by, created_at, expiry
+	         FROM invitations
+					 WHERE accepted_at IS NULL AND expiry > $1
+					 ORDER BY id`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, stmt, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	invitations := []*Invitation{}
+
+	for rows.Next() {
+		var inv Invitation
+
+		err := rows.Scan(
+			&inv.ID,
+			&inv.Email,
+			pq.Array(&inv.Permissions),
+			&inv.OrganizationID,
+			&inv.OrganizationRole,
+			&inv.InvitedBy,
+			&inv.CreatedAt,
+			&inv.Expiry,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		invitations = append(invitations, &inv)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return invitations, nil
+}
+
+func (m *InvitationModel) Delete(id int64) error {
+	stmt := `DELETE FROM invitations
+	         WHERE id = $1 AND accepted_at IS NULL`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, stmt, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrNoRecord
+	}
+
+	return nil
+}
+
+func (m *InvitationModel) Accept(tokenPlaintext string, user *User) (*Invitation, error) {
+	tokenHash := sha256.Sum256([]byte(tokenPlaintext))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	stmt := `SELECT invitations.id, invitations.email, invitations.permissions, invitations.organization_id,
+	         invitations.organization_role, invitations.invited_by, invitations.created_at, invitations.expiry,
+					 COALESCE(users.email, 'invitation')
+	         FROM invitations
+					 LEFT JOIN users ON users.id = invitations.invited_by
+					 WHERE invitations.hash = $1 AND invitations.accepted_at IS NULL AND invitations.expiry > $2
+					 FOR UPDATE OF invitations`
+
+	var (
+		inv   Invitation
+		actor Actor
+	)
+
+	err = tx.QueryRowContext(ctx, stmt, tokenHash[:], time.Now()).Scan(
+		&inv.ID,
+		&inv.Email,
+		pq.Array(&inv.Permissions),
+		&inv.OrganizationID,
+		&inv.OrganizationRole,
+		&inv.InvitedBy,
+		&inv.CreatedAt,
+		&inv.Expiry,
+		&actor.Name,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNoRecord
+		}
+		return nil, err
+	}
+
+	if inv.InvitedBy != nil {
+		actor.ID = *inv.InvitedBy
+	}
+
+	user.Email = inv.Email
+	user.Activated = true
+
+	stmt = `INSERT INTO users (name, email, password_hash, activated)
+	        VALUES ($1, $2, $3, $4)
+					RETURNING id, created_at, version`
+
+	err = tx.QueryRowContext(ctx, stmt, user.Name, user.Email, user.Password.hash, user.Activated).Scan(&user.ID, &user.CreateAt, &user.Version)
+	if err != nil {
+		if err.Error() == emailUniquePQErrMsg {
+			return nil, ErrDuplicateEmail
+		}
+		return nil, err
+	}
+
+	stmt = `INSERT INTO users_permissions
+	        SELECT $1, permissions.id FROM permissions WHERE permissions.code = $2
+					ON CONFLICT DO NOTHING`
+
+	for _, code := range inv.Permissions {
+		_, err = tx.ExecContext(ctx, stmt, user.ID, code)
+		if err != nil {
+			return nil, err
+		}
+
+		err = insertAudit(ctx, tx, actor, &PermissionAudit{Action: AuditActionGrant, UserID: &user.ID, Code: &code})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if inv.OrganizationID != nil {
+		stmt = `INSERT INTO organizations_members (organization_id, user_id, role)
+		        VALUES ($1, $2, $3)`
+
+		_, err = tx.ExecContext(ctx, stmt, *inv.OrganizationID, user.ID, *inv.OrganizationRole)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	stmt = `UPDATE invitations
+	        SET accepted_at = $1, user_id = $2
+					WHERE id = $3`
+
+	_, err = tx.ExecContext(ctx, stmt, time.Now(), user.ID, inv.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return &inv, nil
+}
@@ -0,0 +1,158 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"strings"
+	"time"
+)
+
+type LoginAttempt struct {
+	Key           string
+	Failures      int
+	LastFailureAt time.Time
+	LockedUntil   *time.Time
+}
+
+func (a *LoginAttempt) Locked(now time.Time) bool {
+	return a.LockedUntil != nil && now.Before(*a.LockedUntil)
+}
+
+func (a *LoginAttempt) Expired(now time.Time, window time.Duration) bool {
+	return !a.Locked(now) && now.Sub(a.LastFailureAt) > window
+}
+
+func (a *LoginAttempt) RetryAt(backoff, window time.Duration) time.Time {
+	if a.Failures == 0 {
+		return time.Time{}
+	}
+
+	return a.LastFailureAt.Add(min(backoff<<min(a.Failures-1, 10), window/2))
+}
+
+type LoginLimit struct {
+	Key       string
+	Threshold int
+}
+
+type LoginAttemptModel struct {
+	DB *sql.DB
+}
+
+func (m *LoginAttemptModel) Reserve(limits []LoginLimit, lockout, backoff time.Duration) (bool, []string, error) {
+	limits = slices.Clone(limits)
+	slices.SortFunc(limits, func(a, b LoginLimit) int { return strings.Compare(a.Key, b.Key) })
+
+	now := time.Now()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return false, nil, err
+	}
+
+	defer tx.Rollback()
+
+	attempts := make([]LoginAttempt, len(limits))
+
+	for i, limit := range limits {
+		stmt := `INSERT INTO login_attempts (key, failures, last_failure_at)
+		         VALUES ($1, 0, $2)
+						 ON CONFLICT (key) DO NOTHING`
+
+		_, err = tx.ExecContext(ctx, stmt, limit.Key, now)
+		if err != nil {
+			return false, nil, err
+		}
+
+		stmt = `SELECT key, failures, last_failure_at, locked_until
+		        FROM login_attempts
+						WHERE key = $1
+						FOR UPDATE`
+
+		attempt := &attempts[i]
+
+		err = tx.QueryRowContext(ctx, stmt, limit.Key).Scan(
+			&attempt.Key,
+			&attempt.Failures,
+			&attempt.LastFailureAt,
+			&attempt.LockedUntil,
+		)
+		if err != nil {
+			return false, nil, err
+		}
+
+		if attempt.Expired(now, lockout) {
+			attempt.Failures = 0
+			attempt.LockedUntil = nil
+		}
+
+		if attempt.Locked(now) || now.Before(attempt.RetryAt(backoff, lockout)) {
+			return false, nil, nil
+		}
+	}
+
+	var locked []string
+
+	for i, limit := range limits {
+		attempt := &attempts[i]
+
+		attempt.Failures++
+		attempt.LastFailureAt = now
+
+		if attempt.Failures >= limit.Threshold {
+			lockedUntil := now.Add(lockout)
+			attempt.LockedUntil = &lockedUntil
+			attempt.Failures = 0
+			locked = append(locked, attempt.Key)
+		}
+
+		stmt := `UPDATE login_attempts
+		         SET failures = $2, last_failure_at = $3, locked_until = $4
+						 WHERE key = $1`
+
+		_, err = tx.ExecContext(ctx, stmt, attempt.Key, attempt.Failures, attempt.LastFailureAt, attempt.LockedUntil)
+		if err != nil {
+			return false, nil, err
+		}
+	}
+
+	return true, locked, tx.Commit()
+}
+
+func (m *LoginAttemptModel) Release(key string, unlock bool) error {
+	stmt := `UPDATE login_attempts
+	         SET failures = GREATEST(failures - 1, 0), locked_until = CASE WHEN $2 THEN NULL ELSE locked_until END
+					 WHERE key = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, stmt, key, unlock)
+	return err
+}
+
+func (m *LoginAttemptModel) Delete(key string) error {
+	stmt := `DELETE FROM login_attempts
+	         WHERE key = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, stmt, key)
+	return err
+}
+
+func (m *LoginAttemptModel) DeleteStale(before time.Time) error {
+	stmt := `DELETE FROM login_attempts
+	         WHERE last_failure_at < $1 AND (locked_until IS NULL OR locked_until < $1)`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, stmt, before)
+	return err
+}
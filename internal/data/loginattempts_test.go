@@ -0,0 +1,37 @@
+package data
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoginAttemptRetryAtStaysInsideWindow(t *testing.T) {
+	window := 15 * time.Minute
+	last := time.Now()
+
+	tests := []struct {
+		name     string
+		failures int
+		want     time.Duration
+	}{
+		{"first failure", 1, time.Second},
+		{"doubles", 4, 8 * time.Second},
+		{"capped below window", 11, window / 2},
+		{"many failures", 49, window / 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &LoginAttempt{Failures: tt.failures, LastFailureAt: last}
+
+			got := a.RetryAt(time.Second, window).Sub(last)
+			if got != tt.want {
+				t.Errorf("RetryAt() delay = %v; want %v", got, tt.want)
+			}
+
+			if a.Expired(a.RetryAt(time.Second, window), window) {
+				t.Error("attempt expires before it may be retried")
+			}
+		})
+	}
+}
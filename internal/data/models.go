@@ -3,6 +3,7 @@ package data
 import (
 	"database/sql"
 	"errors"
+	"time"
 )
 
 var (
@@ -11,17 +12,41 @@ var (
 )
 
 type Models struct {
-	Movies      *MovieModel
-	Users       *UserModel
-	Tokens      *TokenModel
-	Permissions *PermissionModel
+	Movies        *MovieModel
+	Users         *UserModel
+	Tokens        *TokenModel
+	Permissions   *PermissionModel
+	APIKeys       *APIKeyModel
+	TOTP          *TOTPModel
+	LoginAttempts *LoginAttemptModel
+	EmailChanges  *EmailChangeModel
+	Roles         *RoleModel
+	Organizations *OrganizationModel
+	Invitations   *InvitationModel
+	Identities    *IdentityModel
+	OAuth         *OAuthModel
+	Certificates  *ClientCertificateModel
+	SigningKeys   *SigningKeyModel
 }
 
-func NewModels(db *sql.DB) *Models {
+func NewModels(db *sql.DB, permissionCacheTTL time.Duration) *Models {
+	permissionCache := NewPermissionCache(permissionCacheTTL)
+
 	return &Models{
-		Movies:      &MovieModel{DB: db},
-		Users:       &UserModel{DB: db},
-		Tokens:      &TokenModel{DB: db},
-		Permissions: &PermissionModel{DB: db},
+		Movies:        &MovieModel{DB: db},
+		Users:         &UserModel{DB: db},
+		Tokens:        &TokenModel{DB: db},
+		Permissions:   &PermissionModel{DB: db, Cache: permissionCache},
+		APIKeys:       &APIKeyModel{DB: db},
+		TOTP:          &TOTPModel{DB: db},
+		LoginAttempts: &LoginAttemptModel{DB: db},
+		EmailChanges:  &EmailChangeModel{DB: db},
+		Roles:         &RoleModel{DB: db, Cache: permissionCache},
+		Organizations: &OrganizationModel{DB: db},
+		Invitations:   &InvitationModel{DB: db},
+		Identities:    &IdentityModel{DB: db},
+		OAuth:         &OAuthModel{DB: db},
+		Certificates:  &ClientCertificateModel{DB: db},
+		SigningKeys:   &SigningKeyModel{DB: db},
 	}
 }
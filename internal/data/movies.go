@@ -12,13 +12,15 @@ import (
 )
 
 type Movie struct {
-	ID        int64     `json:"id"`
-	CreatedAt time.Time `json:"-"`
-	Title     string    `json:"title"`
-	Year      int32     `json:"year,omitempty"`
-	Runtime   int32     `json:"runtime,omitempty"`
-	Genres    []string  `json:"genres,omitempty"`
-	Version   int32     `json:"version"`
+	ID             int64     `json:"id"`
+	CreatedAt      time.Time `json:"-"`
+	Title          string    `json:"title"`
+	Year           int32     `json:"year,omitempty"`
+	Runtime        int32     `json:"runtime,omitempty"`
+	Genres         []string  `json:"genres,omitempty"`
+	CreatedBy      *int64    `json:"created_by,omitempty"`
+	OrganizationID int64     `json:"organization_id"`
+	Version        int32     `json:"version"`
 }
 
 func ValidateMovie(v *validator.Validator, m *Movie) {
@@ -35,11 +37,11 @@ type MovieModel struct {
 }
 
 func (m *MovieModel) Insert(movie *Movie) error {
-	stmt := `INSERT INTO movies (title, year, runtime, genres)
-	         VALUES ($1, $2, $3, $4)
+	stmt := `INSERT INTO movies (title, year, runtime, genres, created_by, organization_id)
+	         VALUES ($1, $2, $3, $4, $5, $6)
 					 RETURNING id, created_at, version`
 
-	args := []any{movie.Title, movie.Year, movie.Runtime, pq.Array(movie.Genres)}
+	args := []any{movie.Title, movie.Year, movie.Runtime, pq.Array(movie.Genres), movie.CreatedBy, movie.OrganizationID}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
@@ -47,23 +49,25 @@ func (m *MovieModel) Insert(movie *Movie) error {
 	return m.DB.QueryRowContext(ctx, stmt, args...).Scan(&movie.ID, &movie.CreatedAt, &movie.Version)
 }
 
-func (m *MovieModel) Get(id int64) (*Movie, error) {
-	stmt := `SELECT id, created_at, title, year, runtime, genres, version
+func (m *MovieModel) Get(orgID, id int64) (*Movie, error) {
+	stmt := `SELECT id, created_at, title, year, runtime, genres, created_by, organization_id, version
 	         FROM movies
-					 WHERE id = $1`
+					 WHERE id = $1 AND organization_id = $2`
 
 	var movie Movie
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	err := m.DB.QueryRowContext(ctx, stmt, id).Scan(
+	err := m.DB.QueryRowContext(ctx, stmt, id, orgID).Scan(
 		&movie.ID,
 		&movie.CreatedAt,
 		&movie.Title,
 		&movie.Year,
 		&movie.Runtime,
 		pq.Array(&movie.Genres),
+		&movie.CreatedBy,
+		&movie.OrganizationID,
 		&movie.Version,
 	)
 	if err != nil {
@@ -79,7 +83,7 @@ func (m *MovieModel) Get(id int64) (*Movie, error) {
 func (m *MovieModel) Update(movie *Movie) error {
 	stmt := `UPDATE movies
 	         SET title = $1, year = $2, runtime = $3, genres = $4, version = version + 1
-					 WHERE id = $5 AND version = $6
+					 WHERE id = $5 AND organization_id = $6 AND version = $7
 					 RETURNING version`
 
 	args := []any{
@@ -88,6 +92,7 @@ func (m *MovieModel) Update(movie *Movie) error {
 		movie.Runtime,
 		pq.Array(movie.Genres),
 		movie.ID,
+		movie.OrganizationID,
 		movie.Version,
 	}
 
@@ -105,14 +110,14 @@ func (m *MovieModel) Update(movie *Movie) error {
 	return nil
 }
 
-func (m *MovieModel) Delete(id int64) error {
+func (m *MovieModel) Delete(orgID, id int64) error {
 	stmt := `DELETE FROM movies
-	         WHERE id = $1`
+	         WHERE id = $1 AND organization_id = $2`
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	result, err := m.DB.ExecContext(ctx, stmt, id)
+	result, err := m.DB.ExecContext(ctx, stmt, id, orgID)
 	if err != nil {
 		return err
 	}
@@ -129,16 +134,18 @@ func (m *MovieModel) Delete(id int64) error {
 	return nil
 }
 
-func (m *MovieModel) GetAll(title string, genres []string, filters *Filters) ([]*Movie, *Metadata, error) {
+func (m *MovieModel) GetAll(orgID int64, title string, genres []string, createdBy *int64, filters *Filters) ([]*Movie, *Metadata, error) {
 	stmt := fmt.Sprintf(`
-	        SELECT count(*) OVER(), id, created_at, title, year, runtime, genres, version
+	        SELECT count(*) OVER(), id, created_at, title, year, runtime, genres, created_by, organization_id, version
 	        FROM movies
-					WHERE (to_tsvector('simple', title) @@ plainto_tsquery('simple', $1) OR $1 = '')
-					AND (genres @> $2 OR $2 = '{}')
+					WHERE organization_id = $1
+					AND (to_tsvector('simple', title) @@ plainto_tsquery('simple', $2) OR $2 = '')
+					AND (genres @> $3 OR $3 = '{}')
+					AND (created_by = $4 OR $4 IS NULL)
 					ORDER BY %s %s, id ASC
-					LIMIT $3 OFFSET $4`, filters.sortColumn(), filters.sortDirection())
+					LIMIT $5 OFFSET $6`, filters.sortColumn(), filters.sortDirection())
 
-	args := []any{title, pq.Array(genres), filters.limit(), filters.offset()}
+	args := []any{orgID, title, pq.Array(genres), createdBy, filters.limit(), filters.offset()}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
@@ -162,6 +169,8 @@ func (m *MovieModel) GetAll(title string, genres []string, filters *Filters) ([]
 			&movie.Year,
 			&movie.Runtime,
 			pq.Array(&movie.Genres),
+			&movie.CreatedBy,
+			&movie.OrganizationID,
 			&movie.Version,
 		)
 		if err != nil {
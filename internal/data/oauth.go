@@ -0,0 +1,414 @@
+package data
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/pharsha1995/greenlight/internal/data/validator"
+)
+
+const OAuthTokenPrefix = "glo_"
+
+type OAuthClient struct {
+	ID           int64       `json:"-"`
+	ClientID     string      `json:"client_id"`
+	Secret       string      `json:"client_secret,omitempty"`
+	SecretHash   []byte      `json:"-"`
+	Confidential bool        `json:"confidential"`
+	Name         string      `json:"name"`
+	RedirectURIs []string    `json:"redirect_uris"`
+	Scopes       Permissions `json:"scopes"`
+	CreatedBy    *int64      `json:"created_by,omitempty"`
+	CreatedAt    time.Time   `json:"created_at"`
+}
+
+func (c *OAuthClient) MatchesSecret(secret string) bool {
+	if !c.Confidential {
+		return secret == ""
+	}
+
+	hash := sha256.Sum256([]byte(secret))
+	return subtle.ConstantTimeCompare(hash[:], c.SecretHash) == 1
+}
+
+func ValidateOAuthClient(v *validator.Validator, client *OAuthClient) {
+	v.Check(validator.ValidString(client.Name, 1, 100), "name", "must not be empty and less than 100 bytes")
+	v.Check(validator.WithinRange(len(client.RedirectURIs), 1, 10), "redirect_uris", "must contain between 1 and 10 URIs")
+	v.Check(validator.Unique(client.RedirectURIs), "redirect_uris", "must not contain duplicate and empty values")
+
+	for _, uri := range client.RedirectURIs {
+		u, err := url.Parse(uri)
+		v.Check(err == nil && (u.Scheme == "https" || u.Scheme == "http") && u.Host != "" && u.Fragment == "", "redirect_uris", "must only contain absolute http(s) URIs without a fragment")
+	}
+
+	v.Check(len(client.Scopes) > 0, "scopes", "must contain at least one permission")
+	ValidateOAuthScopes(v, "scopes", client.Scopes)
+}
+
+func ValidateOAuthScopes(v *validator.Validator, key string, scopes Permissions) {
+	v.Check(validator.Unique(scopes), key, "must not contain duplicate and empty values")
+
+	for _, code := range scopes {
+		ValidatePermissionCode(v, key, code)
+		v.Check(!IsDenyPermission(code), key, "must not contain deny entries")
+	}
+}
+
+type OAuthCode struct {
+	ClientID      int64
+	UserID        int64
+	RedirectURI   string
+	Scopes        Permissions
+	CodeChallenge string
+}
+
+type OAuthToken struct {
+	Plaintext string      `json:"-"`
+	ClientID  string      `json:"client_id"`
+	UserID    int64       `json:"-"`
+	Scopes    Permissions `json:"scopes"`
+	CreatedAt time.Time   `json:"created_at"`
+	Expiry    time.Time   `json:"expiry"`
+}
+
+func IsOAuthToken(plaintext string) bool {
+	return strings.HasPrefix(plaintext, OAuthTokenPrefix)
+}
+
+func ValidateOAuthTokenPlaintext(v *validator.Validator, plaintext string) {
+	v.Check(IsOAuthToken(plaintext), "token", "must be a valid OAuth access token")
+	v.Check(len(plaintext) == len(OAuthTokenPrefix)+52, "token", "must be a valid OAuth access token")
+}
+
+type OAuthModel struct {
+	DB *sql.DB
+}
+
+func (m *OAuthModel) InsertClient(client *OAuthClient) error {
+	clientID := make([]byte, 16)
+
+	_, err := rand.Read(clientID)
+	if err != nil {
+		return err
+	}
+
+	client.ClientID = hex.EncodeToString(clientID)
+
+	if client.Confidential {
+		secret := make([]byte, 32)
+
+		_, err = rand.Read(secret)
+		if err != nil {
+			return err
+		}
+
+		client.Secret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(secret)
+		hash := sha256.Sum256([]byte(client.Secret))
+		client.SecretHash = hash[:]
+	}
+
+	stmt := `INSERT INTO oauth_clients (client_id, secret_hash, name, redirect_uris, scopes, created_by)
+	         VALUES ($1, $2, $3, $4, $5, $6)
+					 RETURNING id, created_at`
+
+	args := []any{
+		client.ClientID,
+		client.SecretHash,
+		client.Name,
+		pq.Array(client.RedirectURIs),
+		pq.Array(client.Scopes),
+		client.CreatedBy,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return m.DB.QueryRowContext(ctx, stmt, args...).Scan(&client.ID, &client.CreatedAt)
+}
+
+func (m *OAuthModel) GetClient(clientID string) (*OAuthClient, error) {
+	stmt := `SELECT id, client_id, secret_hash, name, redirect_uris, scopes, created_by, created_at
+	         FROM oauth_clients
+					 WHERE client_id = $1`
+
+	var client OAuthClient
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, stmt, clientID).Scan(
+		&client.ID,
+		&client.ClientID,
+		&client.SecretHash,
+		&client.Name,
+		pq.Array(&client.RedirectURIs),
+		pq.Array(&client.Scopes),
+		&client.CreatedBy,
+		&client.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNoRecord
+		}
+		return nil, err
+	}
+
+	client.Confidential = client.SecretHash != nil
+
+	return &client, nil
+}
+
+func (m *OAuthModel) GetAllClients() ([]*OAuthClient, error) {
+	stmt := `SELECT id, client_id, secret_hash, name, redirect_uris, scopes, created_by, created_at
+	         FROM oauth_clients
+					 ORDER BY id`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	clients := []*OAuthClient{}
+
+	for rows.Next() {
+		var client OAuthClient
+
+		err := rows.Scan(
+			&client.ID,
+			&client.ClientID,
+			&client.SecretHash,
+			&client.Name,
+			pq.Array(&client.RedirectURIs),
+			pq.Array(&client.Scopes),
+			&client.CreatedBy,
+			&client.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		client.Confidential = client.SecretHash != nil
+
+		clients = append(clients, &client)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return clients, nil
+}
+
+func (m *OAuthModel) DeleteClient(clientID string) error {
+	stmt := `DELETE FROM oauth_clients
+	         WHERE client_id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, stmt, clientID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrNoRecord
+	}
+
+	return nil
+}
+
+func (m *OAuthModel) NewCode(code *OAuthCode, ttl time.Duration) (string, error) {
+	token, err := generateToken(code.UserID, ttl, ScopeOAuthCode)
+	if err != nil {
+		return "", err
+	}
+
+	stmt := `INSERT INTO oauth_codes (hash, client_id, user_id, redirect_uri, scopes, code_challenge, expiry)
+	         VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	args := []any{
+		token.Hash,
+		code.ClientID,
+		code.UserID,
+		code.RedirectURI,
+		pq.Array(code.Scopes),
+		code.CodeChallenge,
+		token.Expiry,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err = m.DB.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		return "", err
+	}
+
+	return token.Plaintext, nil
+}
+
+func (m *OAuthModel) ConsumeCode(plaintext string) (*OAuthCode, error) {
+	hash := sha256.Sum256([]byte(plaintext))
+
+	stmt := `DELETE FROM oauth_codes
+	         WHERE hash = $1 AND expiry > $2
+					 RETURNING client_id, user_id, redirect_uri, scopes, code_challenge`
+
+	var code OAuthCode
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, stmt, hash[:], time.Now()).Scan(
+		&code.ClientID,
+		&code.UserID,
+		&code.RedirectURI,
+		pq.Array(&code.Scopes),
+		&code.CodeChallenge,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNoRecord
+		}
+		return nil, err
+	}
+
+	return &code, nil
+}
+
+func (m *OAuthModel) NewAccessToken(client *OAuthClient, userID int64, scopes Permissions, ttl time.Duration) (*OAuthToken, error) {
+	randomBytes := make([]byte, 32)
+
+	_, err := rand.Read(randomBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	token := &OAuthToken{
+		Plaintext: OAuthTokenPrefix + base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(randomBytes),
+		ClientID:  client.ClientID,
+		UserID:    userID,
+		Scopes:    scopes,
+		CreatedAt: time.Now(),
+	}
+
+	token.Expiry = token.CreatedAt.Add(ttl)
+	hash := sha256.Sum256([]byte(token.Plaintext))
+
+	stmt := `INSERT INTO oauth_tokens (hash, client_id, user_id, scopes, created_at, expiry)
+	         VALUES ($1, $2, $3, $4, $5, $6)`
+
+	args := []any{hash[:], client.ID, userID, pq.Array(scopes), token.CreatedAt, token.Expiry}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err = m.DB.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+func (m *OAuthModel) GetForAccessToken(plaintext string) (*OAuthToken, *User, error) {
+	hash := sha256.Sum256([]byte(plaintext))
+
+	stmt := `SELECT oauth_clients.client_id, oauth_tokens.scopes, oauth_tokens.created_at, oauth_tokens.expiry,
+	         users.id, users.created_at, users.name, users.email, users.password_hash, users.activated, users.version
+	         FROM oauth_tokens
+					 INNER JOIN oauth_clients ON oauth_clients.id = oauth_tokens.client_id
+					 INNER JOIN users ON users.id = oauth_tokens.user_id
+					 WHERE oauth_tokens.hash = $1 AND oauth_tokens.expiry > $2`
+
+	var (
+		token OAuthToken
+		user  User
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, stmt, hash[:], time.Now()).Scan(
+		&token.ClientID,
+		pq.Array(&token.Scopes),
+		&token.CreatedAt,
+		&token.Expiry,
+		&user.ID,
+		&user.CreateAt,
+		&user.Name,
+		&user.Email,
+		&user.Password.hash,
+		&user.Activated,
+		&user.Version,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, ErrNoRecord
+		}
+		return nil, nil, err
+	}
+
+	token.Plaintext = plaintext
+	token.UserID = user.ID
+
+	return &token, &user, nil
+}
+
+func (m *OAuthModel) DeleteAccessToken(plaintext string) error {
+	hash := sha256.Sum256([]byte(plaintext))
+
+	stmt := `DELETE FROM oauth_tokens
+	         WHERE hash = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, stmt, hash[:])
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrNoRecord
+	}
+
+	return nil
+}
+
+func (m *OAuthModel) DeleteAllForUser(userID int64) error {
+	stmt := `DELETE FROM oauth_tokens
+	         WHERE user_id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, stmt, userID)
+	return err
+}
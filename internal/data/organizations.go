@@ -0,0 +1,317 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/pharsha1995/greenlight/internal/data/validator"
+)
+
+const (
+	OrganizationRoleViewer = "viewer"
+	OrganizationRoleEditor = "editor"
+	OrganizationRoleAdmin  = "admin"
+	OrganizationRoleOwner  = "owner"
+)
+
+var OrganizationRoles = []string{
+	OrganizationRoleViewer,
+	OrganizationRoleEditor,
+	OrganizationRoleAdmin,
+	OrganizationRoleOwner,
+}
+
+var (
+	ErrDuplicateMember   = errors.New("organizations: user is already a member")
+	ErrLastOwner         = errors.New("organizations: organization must keep at least one owner")
+	memberUniquePQErrMsg = `pq: duplicate key value violates unique constraint "organizations_members_pkey"`
+)
+
+type Organization struct {
+	ID        int64     `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Name      string    `json:"name"`
+	Role      string    `json:"role,omitempty"`
+}
+
+type Member struct {
+	OrganizationID int64     `json:"organization_id"`
+	UserID         int64     `json:"user_id"`
+	Name           string    `json:"name,omitempty"`
+	Email          string    `json:"email,omitempty"`
+	Role           string    `json:"role"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+func (m *Member) HasRole(role string) bool {
+	return slices.Index(OrganizationRoles, m.Role) >= slices.Index(OrganizationRoles, role)
+}
+
+func ValidateOrganization(v *validator.Validator, org *Organization) {
+	v.Check(validator.ValidString(org.Name, 1, 100), "name", "must not be empty and less than 100 bytes")
+}
+
+func ValidateOrganizationRole(v *validator.Validator, role string) {
+	v.Check(role != "", "role", "must be provided")
+	v.Check(validator.PermittedValue(role, OrganizationRoles...), "role", "must be one of viewer, editor, admin or owner")
+}
+
+type OrganizationModel struct {
+	DB *sql.DB
+}
+
+func (m *OrganizationModel) Insert(org *Organization, ownerID int64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	stmt := `INSERT INTO organizations (name)
+	         VALUES ($1)
+					 RETURNING id, created_at`
+
+	err = tx.QueryRowContext(ctx, stmt, org.Name).Scan(&org.ID, &org.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	stmt = `INSERT INTO organizations_members (organization_id, user_id, role)
+	        VALUES ($1, $2, $3)`
+
+	_, err = tx.ExecContext(ctx, stmt, org.ID, ownerID, OrganizationRoleOwner)
+	if err != nil {
+		return err
+	}
+
+	org.Role = OrganizationRoleOwner
+
+	return tx.Commit()
+}
+
+func (m *OrganizationModel) GetAllForUser(userID int64) ([]*Organization, error) {
+	stmt := `SELECT organizations.id, organizations.created_at, organizations.name, organizations_members.role
+	         FROM organizations
+					 INNER JOIN organizations_members ON organizations_members.organization_id = organizations.id
+					 WHERE organizations_members.user_id = $1
+					 ORDER BY organizations.id`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, stmt, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	orgs := []*Organization{}
+
+	for rows.Next() {
+		var org Organization
+
+		err := rows.Scan(&org.ID, &org.CreatedAt, &org.Name, &org.Role)
+		if err != nil {
+			return nil, err
+		}
+
+		orgs = append(orgs, &org)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return orgs, nil
+}
+
+func (m *OrganizationModel) GetMember(orgID, userID int64) (*Member, error) {
+	stmt := `SELECT organization_id, user_id, role, created_at
+	         FROM organizations_members
+					 WHERE organization_id = $1 AND user_id = $2`
+
+	var member Member
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, stmt, orgID, userID).Scan(
+		&member.OrganizationID,
+		&member.UserID,
+		&member.Role,
+		&member.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNoRecord
+		}
+		return nil, err
+	}
+
+	return &member, nil
+}
+
+func (m *OrganizationModel) GetSoleMembership(userID int64) (*Member, error) {
+	stmt := `SELECT count(*) OVER(), organization_id, user_id, role, created_at
+	         FROM organizations_members
+					 WHERE user_id = $1
+					 LIMIT 1`
+
+	var (
+		total  int
+		member Member
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, stmt, userID).Scan(
+		&total,
+		&member.OrganizationID,
+		&member.UserID,
+		&member.Role,
+		&member.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNoRecord
+		}
+		return nil, err
+	}
+
+	if total != 1 {
+		return nil, ErrNoRecord
+	}
+
+	return &member, nil
+}
+
+func (m *OrganizationModel) GetAllMembers(orgID int64) ([]*Member, error) {
+	stmt := `SELECT organizations_members.organization_id, users.id, users.name, users.email, organizations_members.role, organizations_members.created_at
+	         FROM organizations_members
+					 INNER JOIN users ON users.id = organizations_members.user_id
+					 WHERE organizations_members.organization_id = $1
+					 ORDER BY users.id`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, stmt, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	members := []*Member{}
+
+	for rows.Next() {
+		var member Member
+
+		err := rows.Scan(
+			&member.OrganizationID,
+			&member.UserID,
+			&member.Name,
+			&member.Email,
+			&member.Role,
+			&member.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		members = append(members, &member)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return members, nil
+}
+
+func (m *OrganizationModel) AddMember(member *Member) error {
+	stmt := `INSERT INTO organizations_members (organization_id, user_id, role)
+	         VALUES ($1, $2, $3)
+					 RETURNING created_at`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, stmt, member.OrganizationID, member.UserID, member.Role).Scan(&member.CreatedAt)
+	if err != nil {
+		if err.Error() == memberUniquePQErrMsg {
+			return ErrDuplicateMember
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (m *OrganizationModel) UpdateMemberRole(member *Member) error {
+	stmt := `UPDATE organizations_members
+	         SET role = $3
+					 WHERE organization_id = $1 AND user_id = $2`
+
+	return m.changeMembers(member.OrganizationID, stmt, member.OrganizationID, member.UserID, member.Role)
+}
+
+func (m *OrganizationModel) RemoveMember(orgID, userID int64) error {
+	stmt := `DELETE FROM organizations_members
+	         WHERE organization_id = $1 AND user_id = $2`
+
+	return m.changeMembers(orgID, stmt, orgID, userID)
+}
+
+func (m *OrganizationModel) changeMembers(orgID int64, stmt string, args ...any) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `SELECT id FROM organizations WHERE id = $1 FOR UPDATE`, orgID)
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrNoRecord
+	}
+
+	var owners int
+
+	err = tx.QueryRowContext(ctx, `SELECT count(*) FROM organizations_members WHERE organization_id = $1 AND role = $2`, orgID, OrganizationRoleOwner).Scan(&owners)
+	if err != nil {
+		return err
+	}
+
+	if owners == 0 {
+		return ErrLastOwner
+	}
+
+	return tx.Commit()
+}
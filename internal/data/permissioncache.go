@@ -0,0 +1,127 @@
+package data
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type permissionCacheEntry struct {
+	permissions Permissions
+	expiry      time.Time
+}
+
+type PermissionCache struct {
+	mu          sync.RWMutex
+	ttl         time.Duration
+	entries     map[int64]permissionCacheEntry
+	generations map[int64]uint64
+	generation  uint64
+	base        uint64
+	nextSweep   time.Time
+	hits        atomic.Int64
+	misses      atomic.Int64
+}
+
+func NewPermissionCache(ttl time.Duration) *PermissionCache {
+	return &PermissionCache{
+		ttl:         ttl,
+		entries:     make(map[int64]permissionCacheEntry),
+		generations: make(map[int64]uint64),
+	}
+}
+
+func (c *PermissionCache) Get(userID int64) (Permissions, uint64, bool) {
+	if c == nil || c.ttl <= 0 {
+		return nil, 0, false
+	}
+
+	c.mu.RLock()
+	entry, ok := c.entries[userID]
+	generation := c.generationFor(userID)
+	c.mu.RUnlock()
+
+	if !ok || time.Now().After(entry.expiry) {
+		c.misses.Add(1)
+		return nil, generation, false
+	}
+
+	c.hits.Add(1)
+	return entry.permissions, generation, true
+}
+
+func (c *PermissionCache) Set(userID int64, generation uint64, permissions Permissions) {
+	if c == nil || c.ttl <= 0 {
+		return
+	}
+
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.generationFor(userID) != generation {
+		return
+	}
+
+	if now.After(c.nextSweep) {
+		for id, entry := range c.entries {
+			if now.After(entry.expiry) {
+				delete(c.entries, id)
+			}
+		}
+		c.resetGenerations()
+		c.nextSweep = now.Add(c.ttl)
+	}
+
+	c.entries[userID] = permissionCacheEntry{permissions: permissions, expiry: now.Add(c.ttl)}
+}
+
+func (c *PermissionCache) Invalidate(userID int64) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	delete(c.entries, userID)
+	c.generation++
+	c.generations[userID] = c.generation
+	c.mu.Unlock()
+}
+
+func (c *PermissionCache) InvalidateAll() {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	clear(c.entries)
+	c.resetGenerations()
+	c.mu.Unlock()
+}
+
+func (c *PermissionCache) generationFor(userID int64) uint64 {
+	if generation, ok := c.generations[userID]; ok {
+		return generation
+	}
+
+	return c.base
+}
+
+func (c *PermissionCache) resetGenerations() {
+	c.generation++
+	c.base = c.generation
+	clear(c.generations)
+}
+
+func (c *PermissionCache) Stats() map[string]int64 {
+	c.mu.RLock()
+	entries := len(c.entries)
+	c.mu.RUnlock()
+
+	return map[string]int64{
+		"hits":    c.hits.Load(),
+		"misses":  c.misses.Load(),
+		"entries": int64(entries),
+	}
+}
@@ -0,0 +1,98 @@
+package data
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPermissionCacheSkipsStaleFill(t *testing.T) {
+	tests := []struct {
+		name       string
+		invalidate func(c *PermissionCache)
+		wantCached bool
+	}{
+		{"no invalidation", func(c *PermissionCache) {}, true},
+		{"same user invalidated", func(c *PermissionCache) { c.Invalidate(1) }, false},
+		{"other user invalidated", func(c *PermissionCache) { c.Invalidate(2) }, true},
+		{"all invalidated", func(c *PermissionCache) { c.InvalidateAll() }, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewPermissionCache(time.Minute)
+
+			_, generation, ok := c.Get(1)
+			if ok {
+				t.Fatal("Get() hit on an empty cache")
+			}
+
+			tt.invalidate(c)
+			c.Set(1, generation, Permissions{"movies:read"})
+
+			_, _, ok = c.Get(1)
+			if ok != tt.wantCached {
+				t.Errorf("Get() after Set() ok = %t; want %t", ok, tt.wantCached)
+			}
+		})
+	}
+}
+
+func TestPermissionCacheFillAfterInvalidation(t *testing.T) {
+	c := NewPermissionCache(time.Minute)
+
+	c.Invalidate(1)
+
+	_, generation, _ := c.Get(1)
+	c.Set(1, generation, Permissions{"movies:read"})
+
+	permissions, _, ok := c.Get(1)
+	if !ok || !permissions.Include("movies:read") {
+		t.Errorf("Get() = %v, %t; want cached permissions", permissions, ok)
+	}
+}
+
+func TestPermissionCacheDisabled(t *testing.T) {
+	c := NewPermissionCache(0)
+
+	_, generation, _ := c.Get(1)
+	c.Set(1, generation, Permissions{"movies:read"})
+
+	if _, _, ok := c.Get(1); ok {
+		t.Error("Get() hit with caching disabled")
+	}
+}
+
+func TestPermissionCachePrunesGenerations(t *testing.T) {
+	tests := []struct {
+		name  string
+		prune func(c *PermissionCache)
+	}{
+		{"all invalidated", func(c *PermissionCache) { c.InvalidateAll() }},
+		{"sweep", func(c *PermissionCache) {
+			c.nextSweep = time.Time{}
+			c.Set(3, c.base, Permissions{"movies:read"})
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewPermissionCache(time.Minute)
+
+			_, stale, _ := c.Get(1)
+			c.Invalidate(1)
+			c.Invalidate(2)
+
+			tt.prune(c)
+
+			if len(c.generations) != 0 {
+				t.Errorf("generations has %d entries after pruning; want 0", len(c.generations))
+			}
+
+			c.Set(1, stale, Permissions{"movies:read"})
+
+			if _, _, ok := c.Get(1); ok {
+				t.Error("Get() hit after a fill with a token taken before invalidation")
+			}
+		})
+	}
+}
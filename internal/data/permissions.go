@@ -3,28 +3,120 @@ package data
 import (
 	"context"
 	"database/sql"
-	"slices"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/lib/pq"
+	"github.com/pharsha1995/greenlight/internal/data/validator"
+)
+
+const (
+	AuditActionCreate   = "create"
+	AuditActionGrant    = "grant"
+	AuditActionRevoke   = "revoke"
+	AuditActionAssign   = "assign"
+	AuditActionUnassign = "unassign"
+)
+
+var (
+	ErrDuplicatePermission   = errors.New("permissions: duplicate permission code")
+	ErrUnknownPermission     = errors.New("permissions: unknown permission code")
+	permissionUniquePQErrMsg = `pq: duplicate key value violates unique constraint "permissions_code_key"`
+	PermissionCodeRX         = regexp.MustCompile(`^!?(\*|[a-z0-9_-]+(:[a-z0-9_-]+)*(:\*)?)$`)
 )
 
 type Permissions []string
 
 func (p Permissions) Include(code string) bool {
-	return slices.Contains(p, code)
+	granted := false
+
+	for _, pattern := range p {
+		if deny, ok := strings.CutPrefix(pattern, "!"); ok {
+			if matchPermission(deny, code) {
+				return false
+			}
+		} else if !granted {
+			granted = matchPermission(pattern, code)
+		}
+	}
+
+	return granted
+}
+
+func IsDenyPermission(code string) bool {
+	return strings.HasPrefix(code, "!")
+}
+
+func matchPermission(pattern, code string) bool {
+	if pattern == "*" || pattern == code {
+		return true
+	}
+
+	prefix, ok := strings.CutSuffix(pattern, "*")
+	return ok && strings.HasPrefix(code, prefix)
+}
+
+func ValidatePermissionCode(v *validator.Validator, key, code string) {
+	v.Check(validator.ValidString(code, 1, 100), key, "must not be empty and less than 100 bytes")
+	v.Check(validator.Matches(code, PermissionCodeRX), key, fmt.Sprintf("%q is not a valid permission code", code))
+}
+
+func ValidatePermissionCodes(v *validator.Validator, key string, codes []string) {
+	v.Check(len(codes) > 0, key, "must contain at least one permission")
+	v.Check(validator.Unique(codes), key, "must not contain duplicate and empty values")
+
+	for _, code := range codes {
+		ValidatePermissionCode(v, key, code)
+	}
+}
+
+type Actor struct {
+	ID   int64
+	Name string
+}
+
+func (a Actor) id() *int64 {
+	if a.ID == 0 {
+		return nil
+	}
+	return &a.ID
+}
+
+type PermissionAudit struct {
+	ID        int64     `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	ActorID   *int64    `json:"actor_id,omitempty"`
+	Actor     string    `json:"actor"`
+	Action    string    `json:"action"`
+	UserID    *int64    `json:"user_id,omitempty"`
+	Role      *string   `json:"role,omitempty"`
+	Code      *string   `json:"code,omitempty"`
 }
 
 type PermissionModel struct {
-	DB *sql.DB
+	DB    *sql.DB
+	Cache *PermissionCache
 }
 
 func (m *PermissionModel) GetAllForUser(userID int64) (Permissions, error) {
+	permissions, generation, ok := m.Cache.Get(userID)
+	if ok {
+		return permissions, nil
+	}
+
 	stmt := `SELECT permissions.code
 	         FROM permissions
 					 INNER JOIN users_permissions ON users_permissions.permission_id = permissions.id
-					 INNER JOIN users ON users_permissions.user_id = users.id
-					 WHERE users.id = $1`
+					 WHERE users_permissions.user_id = $1
+					 UNION
+					 SELECT permissions.code
+					 FROM permissions
+					 INNER JOIN roles_permissions ON roles_permissions.permission_id = permissions.id
+					 INNER JOIN users_roles ON users_roles.role_id = roles_permissions.role_id
+					 WHERE users_roles.user_id = $1`
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
@@ -36,8 +128,6 @@ func (m *PermissionModel) GetAllForUser(userID int64) (Permissions, error) {
 
 	defer rows.Close()
 
-	var permissions Permissions
-
 	for rows.Next() {
 		var permission string
 
@@ -53,6 +143,8 @@ func (m *PermissionModel) GetAllForUser(userID int64) (Permissions, error) {
 		return nil, err
 	}
 
+	m.Cache.Set(userID, generation, permissions)
+
 	return permissions, nil
 }
 
@@ -64,5 +156,201 @@ func (m *PermissionModel) AddForUser(userID int64, codes ...string) error {
 	defer cancel()
 
 	_, err := m.DB.ExecContext(ctx, stmt, userID, pq.Array(codes))
+	if err != nil {
+		return err
+	}
+
+	m.Cache.Invalidate(userID)
+
+	return nil
+}
+
+func (m *PermissionModel) GetAll() (Permissions, error) {
+	stmt := `SELECT code
+	         FROM permissions
+					 ORDER BY code`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	permissions := Permissions{}
+
+	for rows.Next() {
+		var permission string
+
+		err := rows.Scan(&permission)
+		if err != nil {
+			return nil, err
+		}
+
+		permissions = append(permissions, permission)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return permissions, nil
+}
+
+func (m *PermissionModel) Insert(actor Actor, code string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO permissions (code) VALUES ($1)`, code)
+	if err != nil {
+		if err.Error() == permissionUniquePQErrMsg {
+			return ErrDuplicatePermission
+		}
+		return err
+	}
+
+	err = insertAudit(ctx, tx, actor, &PermissionAudit{Action: AuditActionCreate, Code: &code})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (m *PermissionModel) Grant(actor Actor, userID int64, codes ...string) error {
+	stmt := `INSERT INTO users_permissions
+					 SELECT $1, permissions.id FROM permissions WHERE permissions.code = $2
+					 ON CONFLICT DO NOTHING`
+
+	return m.change(actor, AuditActionGrant, stmt, userID, codes)
+}
+
+func (m *PermissionModel) Revoke(actor Actor, userID int64, codes ...string) error {
+	stmt := `DELETE FROM users_permissions
+	         USING permissions
+					 WHERE users_permissions.permission_id = permissions.id
+					 AND users_permissions.user_id = $1
+					 AND permissions.code = $2`
+
+	return m.change(actor, AuditActionRevoke, stmt, userID, codes)
+}
+
+func (m *PermissionModel) change(actor Actor, action, stmt string, userID int64, codes []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	var known int
+
+	err = tx.QueryRowContext(ctx, `SELECT count(*) FROM permissions WHERE code = ANY($1)`, pq.Array(codes)).Scan(&known)
+	if err != nil {
+		return err
+	}
+
+	if known != len(codes) {
+		return ErrUnknownPermission
+	}
+
+	for _, code := range codes {
+		result, err := tx.ExecContext(ctx, stmt, userID, code)
+		if err != nil {
+			return err
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rowsAffected == 0 {
+			continue
+		}
+
+		err = insertAudit(ctx, tx, actor, &PermissionAudit{Action: action, UserID: &userID, Code: &code})
+		if err != nil {
+			return err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	m.Cache.Invalidate(userID)
+
+	return nil
+}
+
+func insertAudit(ctx context.Context, tx *sql.Tx, actor Actor, entry *PermissionAudit) error {
+	stmt := `INSERT INTO permissions_audit (actor_id, actor, action, user_id, role, code)
+	         VALUES ($1, $2, $3, $4, $5, $6)`
+
+	args := []any{actor.id(), actor.Name, entry.Action, entry.UserID, entry.Role, entry.Code}
+
+	_, err := tx.ExecContext(ctx, stmt, args...)
 	return err
 }
+
+func (m *PermissionModel) GetAudit(userID *int64, filters *Filters) ([]*PermissionAudit, *Metadata, error) {
+	stmt := fmt.Sprintf(`
+	        SELECT count(*) OVER(), id, created_at, actor_id, actor, action, user_id, role, code
+	        FROM permissions_audit
+					WHERE (user_id = $1 OR $1 IS NULL)
+					ORDER BY %s %s, id ASC
+					LIMIT $2 OFFSET $3`, filters.sortColumn(), filters.sortDirection())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, stmt, userID, filters.limit(), filters.offset())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer rows.Close()
+
+	totalRecords := 0
+	entries := []*PermissionAudit{}
+	for rows.Next() {
+		entry := PermissionAudit{}
+		err := rows.Scan(
+			&totalRecords,
+			&entry.ID,
+			&entry.CreatedAt,
+			&entry.ActorID,
+			&entry.Actor,
+			&entry.Action,
+			&entry.UserID,
+			&entry.Role,
+			&entry.Code,
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		entries = append(entries, &entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	return entries, calculateMetadata(totalRecords, filters.Page, filters.PageSize), nil
+}
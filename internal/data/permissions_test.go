@@ -0,0 +1,84 @@
+package data
+
+import (
+	"testing"
+
+	"github.com/pharsha1995/greenlight/internal/data/validator"
+)
+
+func TestPermissionsInclude(t *testing.T) {
+	tests := []struct {
+		name        string
+		permissions Permissions
+		code        string
+		want        bool
+	}{
+		{"empty", Permissions{}, "movies:read", false},
+		{"nil", nil, "movies:read", false},
+		{"exact match", Permissions{"movies:read"}, "movies:read", true},
+		{"exact mismatch", Permissions{"movies:read"}, "movies:write", false},
+		{"global wildcard", Permissions{"*"}, "users:admin", true},
+		{"namespace wildcard", Permissions{"movies:*"}, "movies:write", true},
+		{"nested namespace wildcard", Permissions{"movies:*"}, "movies:write:own", true},
+		{"wildcard does not match namespace itself", Permissions{"movies:*"}, "movies", false},
+		{"wildcard does not match other namespace", Permissions{"movies:*"}, "users:admin", false},
+		{"wildcard respects segment boundary", Permissions{"movies:*"}, "moviesx:read", false},
+		{"deeper wildcard", Permissions{"movies:write:*"}, "movies:write:own", true},
+		{"deeper wildcard does not match parent", Permissions{"movies:write:*"}, "movies:write", false},
+		{"deny wins over exact grant", Permissions{"movies:write", "!movies:write"}, "movies:write", false},
+		{"deny wins regardless of order", Permissions{"!movies:write", "movies:write"}, "movies:write", false},
+		{"deny wins over wildcard grant", Permissions{"*", "!users:admin"}, "users:admin", false},
+		{"deny leaves other grants intact", Permissions{"*", "!users:admin"}, "movies:read", true},
+		{"wildcard deny", Permissions{"movies:*", "!movies:write:*"}, "movies:write:own", false},
+		{"wildcard deny leaves siblings intact", Permissions{"movies:*", "!movies:write:*"}, "movies:write", true},
+		{"global deny", Permissions{"movies:read", "!*"}, "movies:read", false},
+		{"deny alone grants nothing", Permissions{"!movies:write"}, "movies:read", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.permissions.Include(tt.code); got != tt.want {
+				t.Errorf("%v.Include(%q) = %t; want %t", tt.permissions, tt.code, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidatePermissionCode(t *testing.T) {
+	tests := []struct {
+		code  string
+		valid bool
+	}{
+		{"movies:read", true},
+		{"movies:write:own", true},
+		{"movies:*", true},
+		{"*", true},
+		{"!movies:write", true},
+		{"!*", true},
+		{"", false},
+		{"movies:", false},
+		{":read", false},
+		{"movies:*:read", false},
+		{"movies*", false},
+		{"Movies:Read", false},
+		{"!!movies:read", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			v := validator.New()
+
+			if ValidatePermissionCode(v, "code", tt.code); v.Valid() != tt.valid {
+				t.Errorf("ValidatePermissionCode(%q) valid = %t; want %t", tt.code, v.Valid(), tt.valid)
+			}
+		})
+	}
+}
+
+func BenchmarkPermissionsInclude(b *testing.B) {
+	permissions := Permissions{"movies:read", "movies:write", "users:*", "!users:admin", "permissions:admin"}
+
+	for i := 0; i < b.N; i++ {
+		permissions.Include("permissions:admin")
+	}
+}
@@ -0,0 +1,329 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/pharsha1995/greenlight/internal/data/validator"
+)
+
+var (
+	ErrDuplicateRole   = errors.New("roles: duplicate role name")
+	roleUniquePQErrMsg = `pq: duplicate key value violates unique constraint "roles_name_key"`
+	RoleNameRX         = regexp.MustCompile(`^[a-z0-9_-]+$`)
+)
+
+type Role struct {
+	ID          int64       `json:"id"`
+	Name        string      `json:"name"`
+	Permissions Permissions `json:"permissions"`
+}
+
+func ValidateRole(v *validator.Validator, role *Role) {
+	v.Check(validator.ValidString(role.Name, 1, 100), "name", "must not be empty and less than 100 bytes")
+	v.Check(validator.Matches(role.Name, RoleNameRX), "name", "must only contain lowercase letters, digits, dashes and underscores")
+	v.Check(validator.Unique(role.Permissions), "permissions", "must not contain duplicate and empty values")
+
+	for _, code := range role.Permissions {
+		ValidatePermissionCode(v, "permissions", code)
+	}
+}
+
+type RoleModel struct {
+	DB    *sql.DB
+	Cache *PermissionCache
+}
+
+func (m *RoleModel) GetAll() ([]*Role, error) {
+	stmt := `SELECT roles.id, roles.name, COALESCE(array_agg(permissions.code ORDER BY permissions.code) FILTER (WHERE permissions.code IS NOT NULL), '{}')
+	         FROM roles
+					 LEFT JOIN roles_permissions ON roles_permissions.role_id = roles.id
+					 LEFT JOIN permissions ON permissions.id = roles_permissions.permission_id
+					 GROUP BY roles.id
+					 ORDER BY roles.name`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	roles := []*Role{}
+
+	for rows.Next() {
+		var role Role
+
+		err := rows.Scan(&role.ID, &role.Name, pq.Array(&role.Permissions))
+		if err != nil {
+			return nil, err
+		}
+
+		roles = append(roles, &role)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+func (m *RoleModel) GetAllForUser(userID int64) ([]string, error) {
+	stmt := `SELECT roles.name
+	         FROM roles
+					 INNER JOIN users_roles ON users_roles.role_id = roles.id
+					 WHERE users_roles.user_id = $1
+					 ORDER BY roles.name`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, stmt, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	roles := []string{}
+
+	for rows.Next() {
+		var role string
+
+		err := rows.Scan(&role)
+		if err != nil {
+			return nil, err
+		}
+
+		roles = append(roles, role)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+func (m *RoleModel) Insert(actor Actor, role *Role) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, `INSERT INTO roles (name) VALUES ($1) RETURNING id`, role.Name).Scan(&role.ID)
+	if err != nil {
+		if err.Error() == roleUniquePQErrMsg {
+			return ErrDuplicateRole
+		}
+		return err
+	}
+
+	err = insertAudit(ctx, tx, actor, &PermissionAudit{Action: AuditActionCreate, Role: &role.Name})
+	if err != nil {
+		return err
+	}
+
+	err = m.addPermissions(ctx, tx, actor, role.Name, role.Permissions)
+	if err != nil {
+		return err
+	}
+
+	return m.commit(tx)
+}
+
+func (m *RoleModel) AddPermissions(actor Actor, name string, codes ...string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	err = m.addPermissions(ctx, tx, actor, name, codes)
+	if err != nil {
+		return err
+	}
+
+	return m.commit(tx)
+}
+
+func (m *RoleModel) addPermissions(ctx context.Context, tx *sql.Tx, actor Actor, name string, codes []string) error {
+	stmt := `INSERT INTO roles_permissions
+	         SELECT roles.id, permissions.id FROM roles, permissions
+					 WHERE roles.name = $1 AND permissions.code = $2
+					 ON CONFLICT DO NOTHING`
+
+	return m.change(ctx, tx, actor, AuditActionGrant, stmt, name, codes)
+}
+
+func (m *RoleModel) RemovePermission(actor Actor, name, code string) error {
+	stmt := `DELETE FROM roles_permissions
+	         USING roles, permissions
+					 WHERE roles_permissions.role_id = roles.id
+					 AND roles_permissions.permission_id = permissions.id
+					 AND roles.name = $1
+					 AND permissions.code = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	err = m.change(ctx, tx, actor, AuditActionRevoke, stmt, name, []string{code})
+	if err != nil {
+		return err
+	}
+
+	return m.commit(tx)
+}
+
+func (m *RoleModel) commit(tx *sql.Tx) error {
+	err := tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	m.Cache.InvalidateAll()
+
+	return nil
+}
+
+func (m *RoleModel) change(ctx context.Context, tx *sql.Tx, actor Actor, action, stmt, name string, codes []string) error {
+	var exists bool
+
+	err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM roles WHERE name = $1)`, name).Scan(&exists)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		return ErrNoRecord
+	}
+
+	var known int
+
+	err = tx.QueryRowContext(ctx, `SELECT count(*) FROM permissions WHERE code = ANY($1)`, pq.Array(codes)).Scan(&known)
+	if err != nil {
+		return err
+	}
+
+	if known != len(codes) {
+		return ErrUnknownPermission
+	}
+
+	for _, code := range codes {
+		result, err := tx.ExecContext(ctx, stmt, name, code)
+		if err != nil {
+			return err
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rowsAffected == 0 {
+			continue
+		}
+
+		err = insertAudit(ctx, tx, actor, &PermissionAudit{Action: action, Role: &name, Code: &code})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *RoleModel) AssignToUser(actor Actor, userID int64, names ...string) error {
+	stmt := `INSERT INTO users_roles
+	         SELECT $1, roles.id FROM roles WHERE roles.name = $2
+					 ON CONFLICT DO NOTHING`
+
+	return m.changeForUser(actor, AuditActionAssign, stmt, userID, names)
+}
+
+func (m *RoleModel) UnassignFromUser(actor Actor, userID int64, names ...string) error {
+	stmt := `DELETE FROM users_roles
+	         USING roles
+					 WHERE users_roles.role_id = roles.id
+					 AND users_roles.user_id = $1
+					 AND roles.name = $2`
+
+	return m.changeForUser(actor, AuditActionUnassign, stmt, userID, names)
+}
+
+func (m *RoleModel) changeForUser(actor Actor, action, stmt string, userID int64, names []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	var known int
+
+	err = tx.QueryRowContext(ctx, `SELECT count(*) FROM roles WHERE name = ANY($1)`, pq.Array(names)).Scan(&known)
+	if err != nil {
+		return err
+	}
+
+	if known != len(names) {
+		return ErrNoRecord
+	}
+
+	for _, name := range names {
+		result, err := tx.ExecContext(ctx, stmt, userID, name)
+		if err != nil {
+			return err
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rowsAffected == 0 {
+			continue
+		}
+
+		err = insertAudit(ctx, tx, actor, &PermissionAudit{Action: action, UserID: &userID, Role: &name})
+		if err != nil {
+			return err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	m.Cache.Invalidate(userID)
+
+	return nil
+}
@@ -0,0 +1,251 @@
+package data
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/pharsha1995/greenlight/internal/data/validator"
+)
+
+const SigningKeyPrefix = "glk_"
+
+var (
+	ErrReplayedSignature       = errors.New("signing keys: signature already used")
+	signatureUseUniquePQErrMsg = `pq: duplicate key value violates unique constraint "signing_key_uses_pkey"`
+)
+
+type SigningKey struct {
+	ID              int64       `json:"id"`
+	KeyID           string      `json:"key_id"`
+	Secret          string      `json:"secret,omitempty"`
+	EncryptedSecret []byte      `json:"-"`
+	UserID          int64       `json:"-"`
+	Name            string      `json:"name"`
+	Permissions     Permissions `json:"permissions"`
+	OrganizationID  *int64      `json:"organization_id,omitempty"`
+	CreatedAt       time.Time   `json:"created_at"`
+	LastUsedAt      *time.Time  `json:"last_used_at,omitempty"`
+}
+
+func GenerateSigningKey(key *SigningKey) error {
+	idBytes := make([]byte, 10)
+	secretBytes := make([]byte, 32)
+
+	for _, b := range [][]byte{idBytes, secretBytes} {
+		_, err := rand.Read(b)
+		if err != nil {
+			return err
+		}
+	}
+
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+
+	key.KeyID = SigningKeyPrefix + encoding.EncodeToString(idBytes)
+	key.Secret = encoding.EncodeToString(secretBytes)
+
+	return nil
+}
+
+func SignMessage(secret []byte, message string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(message))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func VerifySignature(secret []byte, message, signature string) bool {
+	return hmac.Equal([]byte(SignMessage(secret, message)), []byte(strings.ToLower(signature)))
+}
+
+func ValidateSigningKey(v *validator.Validator, key *SigningKey) {
+	v.Check(validator.ValidString(key.Name, 1, 100), "name", "must not be empty and less than 100 bytes")
+	v.Check(len(key.Permissions) > 0, "permissions", "must contain at least one permission")
+	v.Check(validator.Unique(key.Permissions), "permissions", "must not contain duplicate and empty values")
+}
+
+func ValidateSigningKeyID(v *validator.Validator, keyID string) {
+	v.Check(strings.HasPrefix(keyID, SigningKeyPrefix), "key_id", "must be a valid signing key ID")
+	v.Check(len(keyID) == len(SigningKeyPrefix)+16, "key_id", "must be a valid signing key ID")
+}
+
+type SigningKeyModel struct {
+	DB *sql.DB
+}
+
+func (m *SigningKeyModel) Insert(key *SigningKey) error {
+	stmt := `INSERT INTO signing_keys (key_id, secret, user_id, name, permissions, organization_id)
+	         VALUES ($1, $2, $3, $4, $5, $6)
+					 RETURNING id, created_at`
+
+	args := []any{key.KeyID, key.EncryptedSecret, key.UserID, key.Name, pq.Array(key.Permissions), key.OrganizationID}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return m.DB.QueryRowContext(ctx, stmt, args...).Scan(&key.ID, &key.CreatedAt)
+}
+
+func (m *SigningKeyModel) GetAllForUser(userID int64) ([]*SigningKey, error) {
+	stmt := `SELECT id, key_id, user_id, name, permissions, organization_id, created_at, last_used_at
+	         FROM signing_keys
+					 WHERE user_id = $1
+					 ORDER BY id`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, stmt, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	keys := []*SigningKey{}
+
+	for rows.Next() {
+		var key SigningKey
+
+		err := rows.Scan(
+			&key.ID,
+			&key.KeyID,
+			&key.UserID,
+			&key.Name,
+			pq.Array(&key.Permissions),
+			&key.OrganizationID,
+			&key.CreatedAt,
+			&key.LastUsedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, &key)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+func (m *SigningKeyModel) Delete(id, userID int64) error {
+	stmt := `DELETE FROM signing_keys
+	         WHERE id = $1 AND user_id = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, stmt, id, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrNoRecord
+	}
+
+	return nil
+}
+
+func (m *SigningKeyModel) GetForKeyID(keyID string) (*SigningKey, *User, error) {
+	stmt := `SELECT signing_keys.id, signing_keys.key_id, signing_keys.secret, signing_keys.name, signing_keys.permissions, signing_keys.organization_id, signing_keys.created_at, signing_keys.last_used_at,
+	         users.id, users.created_at, users.name, users.email, users.password_hash, users.activated, users.version
+	         FROM signing_keys
+					 INNER JOIN users ON users.id = signing_keys.user_id
+					 WHERE signing_keys.key_id = $1`
+
+	var (
+		key  SigningKey
+		user User
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, stmt, keyID).Scan(
+		&key.ID,
+		&key.KeyID,
+		&key.EncryptedSecret,
+		&key.Name,
+		pq.Array(&key.Permissions),
+		&key.OrganizationID,
+		&key.CreatedAt,
+		&key.LastUsedAt,
+		&user.ID,
+		&user.CreateAt,
+		&user.Name,
+		&user.Email,
+		&user.Password.hash,
+		&user.Activated,
+		&user.Version,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, ErrNoRecord
+		}
+		return nil, nil, err
+	}
+
+	key.UserID = user.ID
+
+	return &key, &user, nil
+}
+
+func (m *SigningKeyModel) DeleteAllForUser(userID int64) error {
+	stmt := `DELETE FROM signing_keys
+	         WHERE user_id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, stmt, userID)
+	return err
+}
+
+func (m *SigningKeyModel) RecordUse(keyID, signature string, expiry time.Time) error {
+	stmt := `WITH touched AS (
+	           UPDATE signing_keys SET last_used_at = $4 WHERE key_id = $1
+	         )
+	         INSERT INTO signing_key_uses (key_id, signature, expiry)
+	         VALUES ($1, $2, $3)`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, stmt, keyID, signature, expiry, time.Now())
+	return signatureUseError(err)
+}
+
+func signatureUseError(err error) error {
+	if err != nil && err.Error() == signatureUseUniquePQErrMsg {
+		return ErrReplayedSignature
+	}
+
+	return err
+}
+
+func (m *SigningKeyModel) DeleteExpiredUses() error {
+	stmt := `DELETE FROM signing_key_uses
+	         WHERE expiry < $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, stmt, time.Now())
+	return err
+}
@@ -0,0 +1,35 @@
+package data
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+func TestSignatureUseErrorRejectsReplays(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantReplay bool
+	}{
+		{"recorded", nil, false},
+		{"replayed", &pq.Error{Code: "23505", Message: `duplicate key value violates unique constraint "signing_key_uses_pkey"`}, true},
+		{"other constraint", &pq.Error{Code: "23505", Message: `duplicate key value violates unique constraint "signing_keys_key_id_key"`}, false},
+		{"other error", errors.New("connection refused"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := signatureUseError(tt.err)
+
+			if got := errors.Is(err, ErrReplayedSignature); got != tt.wantReplay {
+				t.Errorf("signatureUseError() = %v; replay %t, want %t", err, got, tt.wantReplay)
+			}
+
+			if (err == nil) != (tt.err == nil) {
+				t.Errorf("signatureUseError() = %v; want an error only when one occurred", err)
+			}
+		})
+	}
+}
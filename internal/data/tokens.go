@@ -6,6 +6,7 @@ import (
 	"crypto/sha256"
 	"database/sql"
 	"encoding/base32"
+	"errors"
 	"time"
 
 	"github.com/pharsha1995/greenlight/internal/data/validator"
@@ -14,21 +15,43 @@ import (
 const (
 	ScopeActivation     = "activation"
 	ScopeAuthentication = "authentication"
+	ScopePasswordReset  = "password-reset"
+	ScopeRefresh        = "refresh"
+	ScopeAPIKey         = "api-key"
+	ScopeTOTPChallenge  = "totp-challenge"
+	ScopeEmailChange    = "email-change"
+	ScopeInvitation     = "invitation"
+	ScopeMagicLink      = "magic-link"
+	ScopeOAuth          = "oauth"
+	ScopeOAuthCode      = "oauth-code"
+	ScopeSession        = "session"
+	ScopeCertificate    = "client-certificate"
+	ScopeSigningKey     = "signing-key"
 )
 
+var ErrTokenReused = errors.New("tokens: refresh token reused")
+
 type Token struct {
-	Plaintext string    `json:"token"`
-	Hash      []byte    `json:"-"`
-	UserID    int64     `json:"-"`
-	Expiry    time.Time `json:"expiry"`
-	Scope     string    `json:"-"`
+	Plaintext      string     `json:"token,omitempty"`
+	Hash           []byte     `json:"-"`
+	UserID         int64      `json:"-"`
+	CreatedAt      time.Time  `json:"created_at"`
+	Expiry         time.Time  `json:"expiry"`
+	LastUsedAt     *time.Time `json:"last_used_at,omitempty"`
+	UserAgent      string     `json:"user_agent,omitempty"`
+	Scope          string     `json:"-"`
+	Family         []byte     `json:"-"`
+	OrganizationID *int64     `json:"-"`
 }
 
 func generateToken(UserID int64, ttl time.Duration, scope string) (*Token, error) {
+	now := time.Now()
+
 	token := &Token{
-		UserID: UserID,
-		Expiry: time.Now().Add(ttl),
-		Scope:  scope,
+		UserID:    UserID,
+		CreatedAt: now,
+		Expiry:    now.Add(ttl),
+		Scope:     scope,
 	}
 
 	randomBytes := make([]byte, 16)
@@ -64,11 +87,35 @@ func (m *TokenModel) New(userID int64, ttl time.Duration, scope string) (*Token,
 	return token, err
 }
 
+func NewTokenFamily() ([]byte, error) {
+	family := make([]byte, 16)
+
+	_, err := rand.Read(family)
+	if err != nil {
+		return nil, err
+	}
+
+	return family, nil
+}
+
+func (m *TokenModel) NewSession(userID int64, ttl time.Duration, scope string, family []byte, userAgent string) (*Token, error) {
+	token, err := generateToken(userID, ttl, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	token.Family = family
+	token.UserAgent = userAgent
+
+	err = m.Insert(token)
+	return token, err
+}
+
 func (m *TokenModel) Insert(token *Token) error {
-	stmt := `INSERT INTO tokens (hash, user_id, expiry, scope)
-	         VALUES ($1, $2, $3, $4)`
+	stmt := `INSERT INTO tokens (hash, user_id, created_at, expiry, scope, user_agent, family)
+	         VALUES ($1, $2, $3, $4, $5, $6, $7)`
 
-	args := []any{token.Hash, token.UserID, token.Expiry, token.Scope}
+	args := []any{token.Hash, token.UserID, token.CreatedAt, token.Expiry, token.Scope, token.UserAgent, token.Family}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
@@ -87,3 +134,177 @@ func (m *TokenModel) DeleteAllForUser(scope string, userID int64) error {
 	_, err := m.DB.ExecContext(ctx, stmt, scope, userID)
 	return err
 }
+
+func (m *TokenModel) DeleteSession(scope, tokenPlaintext string) error {
+	tokenHash := sha256.Sum256([]byte(tokenPlaintext))
+
+	stmt := `DELETE FROM tokens
+	         WHERE (scope = $1 AND hash = $2)
+					 OR family = (SELECT family FROM tokens WHERE scope = $1 AND hash = $2)`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, stmt, scope, tokenHash[:])
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrNoRecord
+	}
+
+	return nil
+}
+
+func (m *TokenModel) GetAllForUser(scope string, userID int64) ([]*Token, error) {
+	stmt := `SELECT hash, user_id, created_at, expiry, last_used_at, user_agent, scope
+	         FROM tokens
+					 WHERE scope = $1 AND user_id = $2 AND expiry > $3 AND used_at IS NULL
+					 ORDER BY created_at DESC`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, stmt, scope, userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	tokens := []*Token{}
+
+	for rows.Next() {
+		var token Token
+
+		err := rows.Scan(
+			&token.Hash,
+			&token.UserID,
+			&token.CreatedAt,
+			&token.Expiry,
+			&token.LastUsedAt,
+			&token.UserAgent,
+			&token.Scope,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		tokens = append(tokens, &token)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+func (m *TokenModel) Touch(scope, tokenPlaintext string) error {
+	tokenHash := sha256.Sum256([]byte(tokenPlaintext))
+
+	stmt := `UPDATE tokens
+	         SET last_used_at = $1
+					 WHERE scope = $2 AND hash = $3
+					 AND (last_used_at IS NULL OR last_used_at < $4)`
+
+	now := time.Now()
+	args := []any{now, scope, tokenHash[:], now.Add(-time.Minute)}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, stmt, args...)
+	return err
+}
+
+func (m *TokenModel) Consume(scope, tokenPlaintext string) (*Token, error) {
+	tokenHash := sha256.Sum256([]byte(tokenPlaintext))
+
+	stmt := `DELETE FROM tokens
+	         WHERE scope = $1 AND hash = $2 AND expiry > $3
+					 RETURNING user_id, created_at, expiry`
+
+	token := Token{
+		Plaintext: tokenPlaintext,
+		Hash:      tokenHash[:],
+		Scope:     scope,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, stmt, scope, token.Hash, time.Now()).Scan(&token.UserID, &token.CreatedAt, &token.Expiry)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNoRecord
+		}
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+func (m *TokenModel) DeleteFamily(family []byte) error {
+	stmt := `DELETE FROM tokens
+	         WHERE family = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, stmt, family)
+	return err
+}
+
+func (m *TokenModel) UseRefreshToken(tokenPlaintext string) (*Token, error) {
+	tokenHash := sha256.Sum256([]byte(tokenPlaintext))
+
+	stmt := `UPDATE tokens
+	         SET used_at = $1, last_used_at = $1
+					 WHERE scope = $2 AND hash = $3 AND used_at IS NULL AND expiry > $1
+					 RETURNING user_id, created_at, expiry, user_agent, family`
+
+	token := Token{
+		Plaintext: tokenPlaintext,
+		Hash:      tokenHash[:],
+		Scope:     ScopeRefresh,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, stmt, time.Now(), ScopeRefresh, token.Hash).Scan(
+		&token.UserID,
+		&token.CreatedAt,
+		&token.Expiry,
+		&token.UserAgent,
+		&token.Family,
+	)
+	if err == nil {
+		return &token, nil
+	}
+
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	stmt = `SELECT user_id, family
+	        FROM tokens
+					WHERE scope = $1 AND hash = $2 AND used_at IS NOT NULL`
+
+	err = m.DB.QueryRowContext(ctx, stmt, ScopeRefresh, token.Hash).Scan(&token.UserID, &token.Family)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNoRecord
+		}
+		return nil, err
+	}
+
+	return &token, ErrTokenReused
+}
@@ -5,25 +5,32 @@ import (
 	"crypto/sha256"
 	"database/sql"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/pharsha1995/greenlight/internal/data/validator"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/pharsha1995/greenlight/internal/hasher"
 )
 
 var (
 	ErrDuplicateEmail   = errors.New("users: duplicate email")
+	ErrUnknownHash      = errors.New("users: unknown password hash format")
 	emailUniquePQErrMsg = `pq: duplicate key value violates unique constraint "users_email_key"`
 	AnonymousUser       = &User{}
 )
 
+var (
+	PasswordHasher hasher.Hasher = hasher.DefaultArgon2id
+	LegacyHashers                = []hasher.Hasher{hasher.Bcrypt{Cost: 12}}
+)
+
 type password struct {
 	plaintext *string
 	hash      []byte
 }
 
 func (p *password) Set(plaintextPassword string) error {
-	hash, err := bcrypt.GenerateFromPassword([]byte(plaintextPassword), 12)
+	hash, err := PasswordHasher.Hash(plaintextPassword)
 	if err != nil {
 		return err
 	}
@@ -34,16 +41,20 @@ func (p *password) Set(plaintextPassword string) error {
 	return nil
 }
 
-func (p *password) Matches(plaintextPassword string) (bool, error) {
-	err := bcrypt.CompareHashAndPassword(p.hash, []byte(plaintextPassword))
-	if err != nil {
-		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
-			return false, nil
+func (p *password) Matches(plaintextPassword string) (bool, bool, error) {
+	if PasswordHasher.Identifies(p.hash) {
+		match, err := PasswordHasher.Matches(plaintextPassword, p.hash)
+		return match, match && PasswordHasher.NeedsRehash(p.hash), err
+	}
+
+	for _, h := range LegacyHashers {
+		if h.Identifies(p.hash) {
+			match, err := h.Matches(plaintextPassword, p.hash)
+			return match, match, err
 		}
-		return false, err
 	}
 
-	return true, nil
+	return false, false, ErrUnknownHash
 }
 
 type User struct {
@@ -66,7 +77,8 @@ func ValidateEmail(v *validator.Validator, email string) {
 }
 
 func ValidatePasswordPlaintext(v *validator.Validator, password string) {
-	v.Check(validator.ValidString(password, 8, 72), "password", "must not be empty and between 8 and 72 bytes")
+	maxLength := PasswordHasher.MaxLength()
+	v.Check(validator.ValidString(password, 8, maxLength), "password", fmt.Sprintf("must not be empty and between 8 and %d bytes", maxLength))
 }
 
 func ValidateUser(v *validator.Validator, user *User) {
@@ -107,6 +119,83 @@ func (m *UserModel) Insert(user *User) error {
 	return nil
 }
 
+func (m *UserModel) Get(id int64) (*User, error) {
+	stmt := `SELECT id, created_at, name, email, password_hash, activated, version
+					 FROM users
+					 WHERE id = $1`
+
+	user := User{}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, stmt, id).Scan(
+		&user.ID,
+		&user.CreateAt,
+		&user.Name,
+		&user.Email,
+		&user.Password.hash,
+		&user.Activated,
+		&user.Version,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNoRecord
+		}
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+func (m *UserModel) GetAll(search string, activated *bool, filters *Filters) ([]*User, *Metadata, error) {
+	stmt := fmt.Sprintf(`
+	        SELECT count(*) OVER(), id, created_at, name, email, password_hash, activated, version
+	        FROM users
+					WHERE (name ILIKE '%%' || $1 || '%%' OR email ILIKE '%%' || $1 || '%%' OR $1 = '')
+					AND (activated = $2 OR $2 IS NULL)
+					ORDER BY %s %s, id ASC
+					LIMIT $3 OFFSET $4`, filters.sortColumn(), filters.sortDirection())
+
+	args := []any{search, activated, filters.limit(), filters.offset()}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer rows.Close()
+
+	totalRecords := 0
+	users := []*User{}
+	for rows.Next() {
+		user := User{}
+		err := rows.Scan(
+			&totalRecords,
+			&user.ID,
+			&user.CreateAt,
+			&user.Name,
+			&user.Email,
+			&user.Password.hash,
+			&user.Activated,
+			&user.Version,
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		users = append(users, &user)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	return users, calculateMetadata(totalRecords, filters.Page, filters.PageSize), nil
+}
+
 func (m *UserModel) GetByEmail(email string) (*User, error) {
 	stmt := `SELECT id, created_at, name, email, password_hash, activated, version
 					 FROM users
@@ -167,6 +256,85 @@ func (m *UserModel) Update(user *User) error {
 	return nil
 }
 
+func (m *UserModel) Delete(id int64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	stmt := `SELECT organizations.id
+	         FROM organizations
+					 INNER JOIN organizations_members ON organizations_members.organization_id = organizations.id
+					 WHERE organizations_members.user_id = $1
+					 ORDER BY organizations.id
+					 FOR UPDATE OF organizations`
+
+	_, err = tx.ExecContext(ctx, stmt, id)
+	if err != nil {
+		return err
+	}
+
+	stmt = `SELECT EXISTS (
+	          SELECT 1
+					  FROM organizations_members AS m
+					  WHERE m.user_id = $1 AND m.role = $2
+					  AND NOT EXISTS (
+					    SELECT 1 FROM organizations_members AS o
+					    WHERE o.organization_id = m.organization_id AND o.user_id <> $1 AND o.role = $2
+					  )
+					  AND EXISTS (
+					    SELECT 1 FROM organizations_members AS o
+					    WHERE o.organization_id = m.organization_id AND o.user_id <> $1
+					  )
+					)`
+
+	var soleOwner bool
+
+	err = tx.QueryRowContext(ctx, stmt, id, OrganizationRoleOwner).Scan(&soleOwner)
+	if err != nil {
+		return err
+	}
+
+	if soleOwner {
+		return ErrLastOwner
+	}
+
+	stmt = `DELETE FROM organizations
+	        WHERE id IN (
+					  SELECT organization_id
+					  FROM organizations_members
+					  WHERE organization_id IN (SELECT organization_id FROM organizations_members WHERE user_id = $1)
+					  GROUP BY organization_id
+					  HAVING bool_and(user_id = $1)
+					)`
+
+	_, err = tx.ExecContext(ctx, stmt, id)
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM users WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrNoRecord
+	}
+
+	return tx.Commit()
+}
+
 func (m *UserModel) GetForToken(tokenScope, tokenPlaintext string) (*User, error) {
 	tokenHash := sha256.Sum256([]byte(tokenPlaintext))
 
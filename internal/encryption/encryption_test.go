@@ -0,0 +1,116 @@
+package encryption
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func newTestEncrypter(t *testing.T, fill byte) *Encrypter {
+	t.Helper()
+
+	e, err := New(bytes.Repeat([]byte{fill}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return e
+}
+
+func TestNewRejectsInvalidKeySizes(t *testing.T) {
+	for _, size := range []int{0, 8, 31, 33} {
+		_, err := New(make([]byte, size))
+		if err == nil {
+			t.Errorf("New() with %d byte key error = nil; want an error", size)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	e := newTestEncrypter(t, 1)
+
+	tests := []struct {
+		name      string
+		plaintext []byte
+		ad        []byte
+	}{
+		{"empty", []byte{}, nil},
+		{"short", []byte("JBSWY3DPEHPK3PXP"), []byte("totp:1")},
+		{"binary", bytes.Repeat([]byte{0, 255}, 512), []byte("totp:42")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ciphertext, err := e.Encrypt(tt.plaintext, tt.ad)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := e.Decrypt(ciphertext, tt.ad)
+			if err != nil {
+				t.Fatalf("Decrypt() error = %v", err)
+			}
+
+			if !bytes.Equal(got, tt.plaintext) {
+				t.Errorf("Decrypt() = %x; want %x", got, tt.plaintext)
+			}
+		})
+	}
+}
+
+func TestEncryptUsesFreshNonce(t *testing.T) {
+	e := newTestEncrypter(t, 1)
+
+	a, err := e.Encrypt([]byte("secret"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := e.Encrypt([]byte("secret"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if bytes.Equal(a, b) {
+		t.Error("Encrypt() produced identical ciphertexts for the same plaintext")
+	}
+}
+
+func TestDecryptRejectsTampering(t *testing.T) {
+	e := newTestEncrypter(t, 1)
+
+	ciphertext, err := e.Encrypt([]byte("JBSWY3DPEHPK3PXP"), []byte("totp:1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	flip := func(i int) []byte {
+		c := bytes.Clone(ciphertext)
+		c[i] ^= 0x01
+		return c
+	}
+
+	tests := []struct {
+		name       string
+		encrypter  *Encrypter
+		ciphertext []byte
+		ad         []byte
+	}{
+		{"flipped nonce byte", e, flip(0), []byte("totp:1")},
+		{"flipped body byte", e, flip(len(ciphertext) / 2), []byte("totp:1")},
+		{"flipped tag byte", e, flip(len(ciphertext) - 1), []byte("totp:1")},
+		{"truncated", e, ciphertext[:len(ciphertext)-1], []byte("totp:1")},
+		{"shorter than nonce", e, ciphertext[:4], []byte("totp:1")},
+		{"wrong additional data", e, ciphertext, []byte("totp:2")},
+		{"wrong key", newTestEncrypter(t, 2), ciphertext, []byte("totp:1")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.encrypter.Decrypt(tt.ciphertext, tt.ad)
+			if !errors.Is(err, ErrInvalidCiphertext) {
+				t.Errorf("Decrypt() error = %v; want %v", err, ErrInvalidCiphertext)
+			}
+		})
+	}
+}
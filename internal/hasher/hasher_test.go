@@ -0,0 +1,176 @@
+package hasher
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+var testArgon2id = Argon2id{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+const legacyBcryptHash = "$2a$04$vFEEOFgLun3mU5vEKQo.juK1PrzNHQ.OjyOeYHTZpsrPdwsvUYVCy"
+
+func TestArgon2idRoundTrip(t *testing.T) {
+	hash, err := testArgon2id.Hash("pa55word")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(string(hash), "$argon2id$v=19$m=64,t=1,p=1$") {
+		t.Errorf("Hash() = %q; want a PHC string with the configured parameters", hash)
+	}
+
+	params, salt, key, err := decodeArgon2id(hash)
+	if err != nil {
+		t.Fatalf("decodeArgon2id() error = %v", err)
+	}
+
+	if params.Memory != 64 || params.Iterations != 1 || params.Parallelism != 1 || len(salt) != 16 || len(key) != 32 {
+		t.Errorf("decodeArgon2id() = %+v, %d byte salt, %d byte key", params, len(salt), len(key))
+	}
+
+	tests := []struct {
+		plaintext string
+		want      bool
+	}{
+		{"pa55word", true},
+		{"pa55worD", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		got, err := testArgon2id.Matches(tt.plaintext, hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got != tt.want {
+			t.Errorf("Matches(%q) = %t; want %t", tt.plaintext, got, tt.want)
+		}
+	}
+
+	if testArgon2id.NeedsRehash(hash) {
+		t.Error("NeedsRehash() = true for a hash with the current parameters")
+	}
+}
+
+func TestArgon2idMatchesUsesEncodedParameters(t *testing.T) {
+	hash, err := testArgon2id.Hash("pa55word")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stronger := testArgon2id
+	stronger.Memory = 128
+	stronger.Iterations = 2
+
+	match, err := stronger.Matches("pa55word", hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !match {
+		t.Error("Matches() = false for a hash created with older parameters")
+	}
+
+	if !stronger.NeedsRehash(hash) {
+		t.Error("NeedsRehash() = false for a hash created with older parameters")
+	}
+}
+
+func TestDecodeArgon2idRejectsMalformedHashes(t *testing.T) {
+	tests := []struct {
+		name string
+		hash string
+	}{
+		{"empty", ""},
+		{"bcrypt", legacyBcryptHash},
+		{"wrong variant", "$argon2i$v=19$m=64,t=1,p=1$c2FsdHNhbHRzYWx0c2FsdA$a2V5"},
+		{"wrong version", "$argon2id$v=16$m=64,t=1,p=1$c2FsdHNhbHRzYWx0c2FsdA$a2V5"},
+		{"missing parameters", "$argon2id$v=19$m=64$c2FsdHNhbHRzYWx0c2FsdA$a2V5"},
+		{"zero iterations", "$argon2id$v=19$m=64,t=0,p=1$c2FsdHNhbHRzYWx0c2FsdA$a2V5"},
+		{"zero parallelism", "$argon2id$v=19$m=64,t=1,p=0$c2FsdHNhbHRzYWx0c2FsdA$a2V5"},
+		{"bad salt encoding", "$argon2id$v=19$m=64,t=1,p=1$!!!$a2V5"},
+		{"bad key encoding", "$argon2id$v=19$m=64,t=1,p=1$c2FsdHNhbHRzYWx0c2FsdA$!!!"},
+		{"empty salt", "$argon2id$v=19$m=64,t=1,p=1$$a2V5"},
+		{"empty key", "$argon2id$v=19$m=64,t=1,p=1$c2FsdHNhbHRzYWx0c2FsdA$"},
+		{"too many segments", "$argon2id$v=19$m=64,t=1,p=1$c2FsdA$a2V5$extra"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, _, err := decodeArgon2id([]byte(tt.hash))
+			if !errors.Is(err, ErrInvalidHash) {
+				t.Errorf("decodeArgon2id(%q) error = %v; want %v", tt.hash, err, ErrInvalidHash)
+			}
+
+			if !testArgon2id.NeedsRehash([]byte(tt.hash)) {
+				t.Errorf("NeedsRehash(%q) = false; want true", tt.hash)
+			}
+		})
+	}
+}
+
+func TestBcryptLegacyVerification(t *testing.T) {
+	h := Bcrypt{Cost: 4}
+
+	tests := []struct {
+		name      string
+		plaintext string
+		want      bool
+	}{
+		{"correct password", "pa55word", true},
+		{"wrong password", "pa55worD", false},
+		{"longer than bcrypt limit", strings.Repeat("a", 73), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := h.Matches(tt.plaintext, []byte(legacyBcryptHash))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got != tt.want {
+				t.Errorf("Matches(%q) = %t; want %t", tt.plaintext, got, tt.want)
+			}
+		})
+	}
+
+	if h.NeedsRehash([]byte(legacyBcryptHash)) {
+		t.Error("NeedsRehash() = true for a hash with the current cost")
+	}
+
+	if !(Bcrypt{Cost: 12}).NeedsRehash([]byte(legacyBcryptHash)) {
+		t.Error("NeedsRehash() = false for a hash with a lower cost")
+	}
+}
+
+func TestIdentifies(t *testing.T) {
+	argon2Hash, err := testArgon2id.Hash("pa55word")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		hasher Hasher
+		hash   string
+		want   bool
+	}{
+		{"argon2id identifies argon2id", testArgon2id, string(argon2Hash), true},
+		{"argon2id rejects bcrypt", testArgon2id, legacyBcryptHash, false},
+		{"bcrypt identifies $2a$", Bcrypt{}, legacyBcryptHash, true},
+		{"bcrypt identifies $2b$", Bcrypt{}, "$2b$" + legacyBcryptHash[4:], true},
+		{"bcrypt identifies $2y$", Bcrypt{}, "$2y$" + legacyBcryptHash[4:], true},
+		{"bcrypt rejects argon2id", Bcrypt{}, string(argon2Hash), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.hasher.Identifies([]byte(tt.hash)); got != tt.want {
+				t.Errorf("Identifies(%q) = %t; want %t", tt.hash, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package jwt
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func forge(t *testing.T, h header, c Claims, secret []byte) string {
+	t.Helper()
+
+	hb, err := json.Marshal(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cb, err := json.Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unsigned := encoding.EncodeToString(hb) + "." + encoding.EncodeToString(cb)
+
+	return unsigned + "." + encoding.EncodeToString(sign(secret, unsigned))
+}
+
+func TestSignerRoundTrip(t *testing.T) {
+	signer, err := New("greenlight", []Key{{ID: "k1", Secret: []byte("secret-one")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, expiry, err := signer.Sign(Claims{Subject: "42", Activated: true, Permissions: []string{"movies:read"}}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if time.Until(expiry) > time.Minute || time.Until(expiry) < 58*time.Second {
+		t.Errorf("expiry = %v; want about one minute from now", expiry)
+	}
+
+	claims, err := signer.Verify(token)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+
+	if claims.Subject != "42" || !claims.Activated || len(claims.Permissions) != 1 || claims.Permissions[0] != "movies:read" {
+		t.Errorf("Verify() claims = %+v", claims)
+	}
+}
+
+func TestSignerVerify(t *testing.T) {
+	current := Key{ID: "k2", Secret: []byte("secret-two")}
+	previous := Key{ID: "k1", Secret: []byte("secret-one")}
+
+	signer, err := New("greenlight", []Key{current, previous})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	valid := Claims{Issuer: "greenlight", Subject: "42", IssuedAt: now.Unix(), ExpiresAt: now.Add(time.Minute).Unix()}
+	expired := valid
+	expired.ExpiresAt = now.Add(-time.Second).Unix()
+	otherIssuer := valid
+	otherIssuer.Issuer = "someone-else"
+
+	hs256 := func(kid string) header { return header{Algorithm: "HS256", Type: "JWT", KeyID: kid} }
+
+	good := forge(t, hs256("k2"), valid, current.Secret)
+	parts := strings.Split(good, ".")
+
+	tamperedClaims, _ := json.Marshal(Claims{Issuer: "greenlight", Subject: "1", IssuedAt: valid.IssuedAt, ExpiresAt: valid.ExpiresAt})
+	tamperedSignature := []byte(parts[2])
+	if tamperedSignature[0] == 'A' {
+		tamperedSignature[0] = 'B'
+	} else {
+		tamperedSignature[0] = 'A'
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{"current key", good, nil},
+		{"rotated key still verifies", forge(t, hs256("k1"), valid, previous.Secret), nil},
+		{"unknown kid", forge(t, hs256("k9"), valid, []byte("secret-nine")), ErrUnknownKey},
+		{"wrong secret for kid", forge(t, hs256("k2"), valid, previous.Secret), ErrInvalidToken},
+		{"tampered claims", parts[0] + "." + encoding.EncodeToString(tamperedClaims) + "." + parts[2], ErrInvalidToken},
+		{"tampered signature", parts[0] + "." + parts[1] + "." + string(tamperedSignature), ErrInvalidToken},
+		{"missing signature", parts[0] + "." + parts[1] + ".", ErrInvalidToken},
+		{"alg none", forge(t, header{Algorithm: "none", Type: "JWT", KeyID: "k2"}, valid, current.Secret), ErrInvalidToken},
+		{"alg RS256", forge(t, header{Algorithm: "RS256", Type: "JWT", KeyID: "k2"}, valid, current.Secret), ErrInvalidToken},
+		{"alg HS512", forge(t, header{Algorithm: "HS512", Type: "JWT", KeyID: "k2"}, valid, current.Secret), ErrInvalidToken},
+		{"expired", forge(t, hs256("k2"), expired, current.Secret), ErrExpiredToken},
+		{"wrong issuer", forge(t, hs256("k2"), otherIssuer, current.Secret), ErrInvalidToken},
+		{"two segments", parts[0] + "." + parts[1], ErrInvalidToken},
+		{"garbage header", "!!!." + parts[1] + "." + parts[2], ErrInvalidToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := signer.Verify(tt.token)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Verify() error = %v; want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewRequiresKeys(t *testing.T) {
+	_, err := New("greenlight", nil)
+	if !errors.Is(err, ErrNoKeys) {
+		t.Errorf("New() error = %v; want %v", err, ErrNoKeys)
+	}
+}
@@ -0,0 +1,204 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+type stubIdP struct {
+	server    *httptest.Server
+	key       *rsa.PrivateKey
+	clientID  string
+	secret    string
+	challenge string
+	claims    map[string]any
+}
+
+func newStubIdP(t *testing.T) *stubIdP {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	idp := &stubIdP{key: key, clientID: "greenlight", secret: "s3cret"}
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.server.URL,
+			"authorization_endpoint": idp.server.URL + "/authorize",
+			"token_endpoint":         idp.server.URL + "/token",
+			"jwks_uri":               idp.server.URL + "/jwks",
+		})
+	})
+
+	mux.HandleFunc("GET /jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "test",
+				"use": "sig",
+				"n":   encoding.EncodeToString(key.N.Bytes()),
+				"e":   encoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		id, secret, _ := r.BasicAuth()
+		if id != idp.clientID || secret != idp.secret {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_client"})
+			return
+		}
+
+		if r.PostFormValue("code") != "good-code" || Challenge(r.PostFormValue("code_verifier")) != idp.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]string{"id_token": idp.sign(t, "test", idp.claims)})
+	})
+
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+
+	return idp
+}
+
+func (idp *stubIdP) sign(t *testing.T, kid string, claims map[string]any) string {
+	t.Helper()
+
+	h, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": kid, "typ": "JWT"})
+	c, _ := json.Marshal(claims)
+
+	unsigned := encoding.EncodeToString(h) + "." + encoding.EncodeToString(c)
+	digest := sha256.Sum256([]byte(unsigned))
+
+	signature, err := rsa.SignPKCS1v15(rand.Reader, idp.key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return unsigned + "." + encoding.EncodeToString(signature)
+}
+
+func (idp *stubIdP) provider(t *testing.T) *Provider {
+	t.Helper()
+
+	p, err := Discover(context.Background(), Config{
+		Issuer:       idp.server.URL,
+		ClientID:     idp.clientID,
+		ClientSecret: idp.secret,
+		RedirectURL:  "http://localhost:3000/callback",
+		HTTPClient:   idp.server.Client(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return p
+}
+
+func (idp *stubIdP) validClaims(nonce string) map[string]any {
+	return map[string]any{
+		"iss":            idp.server.URL,
+		"sub":            "user-123",
+		"aud":            idp.clientID,
+		"exp":            time.Now().Add(time.Minute).Unix(),
+		"iat":            time.Now().Unix(),
+		"nonce":          nonce,
+		"email":          "alice@example.com",
+		"email_verified": true,
+	}
+}
+
+func TestAuthorizationCodeFlow(t *testing.T) {
+	idp := newStubIdP(t)
+	p := idp.provider(t)
+
+	verifier, _ := RandomString()
+	idp.challenge = Challenge(verifier)
+	idp.claims = idp.validClaims("n-1")
+
+	authURL, err := url.Parse(p.AuthCodeURL("s-1", "n-1", verifier))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	q := authURL.Query()
+	if q.Get("code_challenge") != idp.challenge || q.Get("code_challenge_method") != "S256" || q.Get("state") != "s-1" {
+		t.Fatalf("unexpected authorization URL %s", authURL)
+	}
+
+	raw, err := p.Exchange(context.Background(), "good-code", verifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims, err := p.Verify(context.Background(), raw, "n-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if claims.Subject != "user-123" || claims.Email != "alice@example.com" || !claims.EmailVerified {
+		t.Errorf("unexpected claims %+v", claims)
+	}
+
+	_, err = p.Exchange(context.Background(), "good-code", "wrong-verifier")
+	if err == nil {
+		t.Error("Exchange with wrong PKCE verifier succeeded")
+	}
+}
+
+func TestVerify(t *testing.T) {
+	idp := newStubIdP(t)
+	p := idp.provider(t)
+
+	tests := []struct {
+		name   string
+		kid    string
+		modify func(map[string]any)
+		want   error
+	}{
+		{"valid", "test", func(c map[string]any) {}, nil},
+		{"audience array", "test", func(c map[string]any) { c["aud"] = []string{"other", idp.clientID} }, nil},
+		{"wrong audience", "test", func(c map[string]any) { c["aud"] = "other" }, ErrInvalidIDToken},
+		{"wrong issuer", "test", func(c map[string]any) { c["iss"] = "https://evil.example.com" }, ErrInvalidIDToken},
+		{"expired", "test", func(c map[string]any) { c["exp"] = time.Now().Add(-time.Minute).Unix() }, ErrExpiredIDToken},
+		{"wrong nonce", "test", func(c map[string]any) { c["nonce"] = "other" }, ErrNonceMismatch},
+		{"unknown key", "rotated", func(c map[string]any) {}, ErrUnknownKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := idp.validClaims("nonce")
+			tt.modify(claims)
+
+			_, err := p.Verify(context.Background(), idp.sign(t, tt.kid, claims), "nonce")
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Verify() error = %v; want %v", err, tt.want)
+			}
+		})
+	}
+
+	_, err := p.Verify(context.Background(), idp.sign(t, "test", idp.validClaims("nonce"))+"x", "nonce")
+	if !errors.Is(err, ErrInvalidIDToken) {
+		t.Errorf("Verify() with tampered signature error = %v; want %v", err, ErrInvalidIDToken)
+	}
+}
@@ -0,0 +1,116 @@
+package totp
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCodeRFC6238(t *testing.T) {
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, tt := range tests {
+		t.Run(time.Unix(tt.unix, 0).UTC().Format(time.RFC3339), func(t *testing.T) {
+			got, err := Code(rfc6238Secret, Step(time.Unix(tt.unix, 0)))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got != tt.want {
+				t.Errorf("Code() = %q; want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCodeAcceptsLowercaseSecret(t *testing.T) {
+	got, err := Code(strings.ToLower(rfc6238Secret), Step(time.Unix(59, 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got != "287082" {
+		t.Errorf("Code() = %q; want %q", got, "287082")
+	}
+}
+
+func TestCodeRejectsInvalidSecret(t *testing.T) {
+	_, err := Code("not base32!", 1)
+	if err == nil {
+		t.Error("Code() error = nil; want an error")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := Step(now)
+
+	codeAt := func(step int64) string {
+		code, err := Code(rfc6238Secret, step)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return code
+	}
+
+	tests := []struct {
+		name     string
+		code     string
+		valid    bool
+		wantStep int64
+	}{
+		{"current step", codeAt(current), true, current},
+		{"previous step", codeAt(current - 1), true, current - 1},
+		{"next step", codeAt(current + 1), true, current + 1},
+		{"two steps behind", codeAt(current - 2), false, 0},
+		{"two steps ahead", codeAt(current + 2), false, 0},
+		{"too short", "12345", false, 0},
+		{"too long", "1234567", false, 0},
+		{"empty", "", false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, valid, err := Validate(rfc6238Secret, tt.code, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if valid != tt.valid || step != tt.wantStep {
+				t.Errorf("Validate(%q) = (%d, %t); want (%d, %t)", tt.code, step, valid, tt.wantStep, tt.valid)
+			}
+		})
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	a, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if a == b {
+		t.Error("GenerateSecret() returned the same secret twice")
+	}
+
+	if _, err := Code(a, 1); err != nil {
+		t.Errorf("Code() with generated secret error = %v", err)
+	}
+}
@@ -0,0 +1,5 @@
+DELETE FROM permissions WHERE code = 'permissions:admin';
+
+DROP TABLE IF EXISTS permissions_audit;
+
+ALTER TABLE permissions DROP CONSTRAINT IF EXISTS permissions_code_key;
@@ -0,0 +1,16 @@
+ALTER TABLE permissions ADD CONSTRAINT permissions_code_key UNIQUE (code);
+
+CREATE TABLE IF NOT EXISTS permissions_audit (
+  id bigserial PRIMARY KEY,
+  created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+  actor_id bigint REFERENCES users ON DELETE SET NULL,
+  actor text NOT NULL,
+  action text NOT NULL,
+  user_id bigint,
+  code text NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS permissions_audit_user_id_idx ON permissions_audit (user_id);
+
+INSERT INTO permissions (code)
+VALUES ('permissions:admin');
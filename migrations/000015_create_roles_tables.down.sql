@@ -0,0 +1,8 @@
+DELETE FROM permissions_audit WHERE code IS NULL;
+
+ALTER TABLE permissions_audit DROP COLUMN IF EXISTS role;
+ALTER TABLE permissions_audit ALTER COLUMN code SET NOT NULL;
+
+DROP TABLE IF EXISTS users_roles;
+DROP TABLE IF EXISTS roles_permissions;
+DROP TABLE IF EXISTS roles;
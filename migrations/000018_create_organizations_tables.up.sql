@@ -0,0 +1,57 @@
+CREATE TABLE IF NOT EXISTS organizations (
+  id bigserial PRIMARY KEY,
+  created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+  name text NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS organizations_members (
+  organization_id bigint NOT NULL REFERENCES organizations ON DELETE CASCADE,
+  user_id bigint NOT NULL REFERENCES users ON DELETE CASCADE,
+  role text NOT NULL,
+  created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (organization_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS organizations_members_user_id_idx ON organizations_members (user_id);
+
+INSERT INTO organizations (name)
+VALUES ('Default');
+
+INSERT INTO organizations_members (organization_id, user_id, role)
+SELECT organizations.id, users.id, 'editor'
+FROM organizations, users;
+
+UPDATE organizations_members
+SET role = 'owner'
+WHERE organization_id = (SELECT min(id) FROM organizations)
+AND user_id IN (
+  SELECT users_permissions.user_id
+  FROM users_permissions
+  INNER JOIN permissions ON permissions.id = users_permissions.permission_id
+  WHERE permissions.code IN ('users:admin', '*')
+  UNION
+  SELECT users_roles.user_id
+  FROM users_roles
+  INNER JOIN roles_permissions ON roles_permissions.role_id = users_roles.role_id
+  INNER JOIN permissions ON permissions.id = roles_permissions.permission_id
+  WHERE permissions.code IN ('users:admin', '*')
+);
+
+UPDATE organizations_members
+SET role = 'owner'
+WHERE (organization_id, user_id) IN (
+  SELECT organization_id, min(user_id)
+  FROM organizations_members
+  GROUP BY organization_id
+  HAVING NOT bool_or(role = 'owner')
+);
+
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS organization_id bigint REFERENCES organizations ON DELETE CASCADE;
+
+UPDATE movies SET organization_id = (SELECT min(id) FROM organizations);
+
+ALTER TABLE movies ALTER COLUMN organization_id SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS movies_organization_id_idx ON movies (organization_id);
+
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS organization_id bigint REFERENCES organizations ON DELETE CASCADE;
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS invitations (
+  id bigserial PRIMARY KEY,
+  hash bytea UNIQUE NOT NULL,
+  email citext NOT NULL,
+  permissions text[] NOT NULL,
+  organization_id bigint REFERENCES organizations ON DELETE CASCADE,
+  organization_role text,
+  invited_by bigint REFERENCES users ON DELETE SET NULL,
+  created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+  expiry timestamp(0) with time zone NOT NULL,
+  accepted_at timestamp(0) with time zone,
+  user_id bigint REFERENCES users ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS invitations_email_idx ON invitations (email);
@@ -0,0 +1,7 @@
+DELETE FROM permissions WHERE code = 'oauth:admin';
+
+DROP TABLE IF EXISTS oauth_tokens;
+
+DROP TABLE IF EXISTS oauth_codes;
+
+DROP TABLE IF EXISTS oauth_clients;
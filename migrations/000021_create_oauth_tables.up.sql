@@ -0,0 +1,34 @@
+CREATE TABLE IF NOT EXISTS oauth_clients (
+  id bigserial PRIMARY KEY,
+  client_id text UNIQUE NOT NULL,
+  secret_hash bytea,
+  name text NOT NULL,
+  redirect_uris text[] NOT NULL,
+  scopes text[] NOT NULL,
+  created_by bigint REFERENCES users ON DELETE SET NULL,
+  created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS oauth_codes (
+  hash bytea PRIMARY KEY,
+  client_id bigint NOT NULL REFERENCES oauth_clients ON DELETE CASCADE,
+  user_id bigint NOT NULL REFERENCES users ON DELETE CASCADE,
+  redirect_uri text NOT NULL,
+  scopes text[] NOT NULL,
+  code_challenge text NOT NULL,
+  expiry timestamp(0) with time zone NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS oauth_tokens (
+  hash bytea PRIMARY KEY,
+  client_id bigint NOT NULL REFERENCES oauth_clients ON DELETE CASCADE,
+  user_id bigint NOT NULL REFERENCES users ON DELETE CASCADE,
+  scopes text[] NOT NULL,
+  created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+  expiry timestamp(0) with time zone NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS oauth_tokens_user_id_idx ON oauth_tokens (user_id);
+
+INSERT INTO permissions (code)
+VALUES ('oauth:admin');
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS client_certificates (
+  id bigserial PRIMARY KEY,
+  subject text NOT NULL UNIQUE,
+  user_id bigint NOT NULL REFERENCES users ON DELETE CASCADE,
+  organization_id bigint REFERENCES organizations ON DELETE CASCADE,
+  created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS client_certificates_user_id_idx ON client_certificates (user_id);
@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS signing_keys (
+  id bigserial PRIMARY KEY,
+  key_id text NOT NULL UNIQUE,
+  secret bytea NOT NULL,
+  user_id bigint NOT NULL REFERENCES users ON DELETE CASCADE,
+  name text NOT NULL,
+  permissions text[] NOT NULL,
+  organization_id bigint REFERENCES organizations ON DELETE CASCADE,
+  created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+  last_used_at timestamp(0) with time zone
+);
+
+CREATE INDEX IF NOT EXISTS signing_keys_user_id_idx ON signing_keys (user_id);
+
+CREATE TABLE IF NOT EXISTS signing_key_uses (
+  key_id text NOT NULL REFERENCES signing_keys (key_id) ON DELETE CASCADE,
+  signature text NOT NULL,
+  expiry timestamp(0) with time zone NOT NULL,
+  PRIMARY KEY (key_id, signature)
+);
+
+CREATE INDEX IF NOT EXISTS signing_key_uses_expiry_idx ON signing_key_uses (expiry);